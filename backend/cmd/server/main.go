@@ -23,6 +23,16 @@ func main() {
 
 	// Veritabanı bağlantısı
 	db := database.Connect(cfg)
+
+	// "server migrate up|down|status" — sadece migration çalıştırıp çık
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(db, os.Args[2:])
+		return
+	}
+
+	if err := database.MigrateUp(db); err != nil {
+		log.Fatalf("Migration hatası: %v", err)
+	}
 	database.Seed(db)
 
 	// Services
@@ -30,6 +40,11 @@ func main() {
 	expenseService := services.NewExpenseService(db)
 	recurringService := services.NewRecurringService(db)
 	settlementService := services.NewSettlementService(db)
+	householdService := services.NewHouseholdService(db)
+	inviteService := services.NewInviteService(db)
+	periodService := services.NewPeriodService(db)
+	auditService := services.NewAuditService(db)
+	jobService := services.NewJobService(db)
 
 	// Handlers
 	authHandler := handlers.NewAuthHandler(authService)
@@ -38,9 +53,14 @@ func main() {
 	recurringHandler := handlers.NewRecurringHandler(recurringService)
 	summaryHandler := handlers.NewSummaryHandler(settlementService)
 	settlementHandler := handlers.NewSettlementHandler(settlementService)
+	householdHandler := handlers.NewHouseholdHandler(householdService)
+	inviteHandler := handlers.NewInviteHandler(inviteService)
+	periodHandler := handlers.NewPeriodHandler(periodService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	jobHandler := handlers.NewJobHandler(jobService, recurringService)
 
 	// Scheduler
-	cron := scheduler.Start(recurringService)
+	cron := scheduler.Start(recurringService, jobService)
 	defer cron.Stop()
 
 	// Echo
@@ -53,6 +73,7 @@ func main() {
 			AllowOrigins:     []string{corsOrigin},
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
 			AllowHeaders:     []string{"Content-Type", "Authorization"},
+			ExposeHeaders:    []string{handlers.HeaderTotalCount, handlers.HeaderTotalAmount, handlers.HeaderNextCursor},
 			AllowCredentials: true,
 		}))
 	}
@@ -96,6 +117,7 @@ func main() {
 
 	// Auth (public)
 	api.POST("/auth/login", authHandler.Login)
+	api.POST("/auth/register", authHandler.Register)
 
 	// Auth gerektiren rotalar
 	auth := api.Group("", middleware.AuthMiddleware(cfg.JWTSecret))
@@ -103,11 +125,30 @@ func main() {
 	auth.POST("/auth/logout", authHandler.Logout)
 	auth.GET("/auth/me", authHandler.Me)
 	auth.POST("/auth/change-password", authHandler.ChangePassword)
+	auth.POST("/auth/switch-household", authHandler.SwitchHousehold)
+
+	// Haneler
+	auth.GET("/households", householdHandler.ListMine)
+	auth.GET("/households/members", householdHandler.Members)
+	auth.GET("/households/settings", householdHandler.Settings)
+	auth.PUT("/households/settings", householdHandler.UpdateSettings, middleware.HouseholdAdminMiddleware())
+
+	// Davetler — oluşturma tüm üyelere, listeleme/iptal hane yöneticisine açık
+	auth.POST("/invites", inviteHandler.Create)
+	auth.GET("/invites", inviteHandler.List, middleware.HouseholdAdminMiddleware())
+	auth.DELETE("/invites/:id", inviteHandler.Revoke, middleware.HouseholdAdminMiddleware())
 
 	// Admin rotaları
 	admin := auth.Group("/admin", middleware.AdminMiddleware())
 	admin.GET("/users", authHandler.ListUsers)
 	admin.POST("/users/:id/reset-password", authHandler.AdminResetPassword)
+	admin.GET("/households", householdHandler.ListAll)
+	admin.POST("/households", householdHandler.Create)
+	admin.POST("/households/:id/members", householdHandler.AddMember)
+	admin.GET("/audit", auditHandler.ListAll)
+	admin.POST("/recurring/backfill", recurringHandler.Backfill)
+	admin.GET("/jobs", jobHandler.List)
+	admin.POST("/jobs/recurring/run", jobHandler.RunRecurring)
 
 	// Kategoriler
 	auth.GET("/categories", categoryHandler.List)
@@ -122,6 +163,11 @@ func main() {
 	auth.POST("/expenses/:id/reject", expenseHandler.Reject)
 	auth.POST("/expenses/:id/confirm-delete", expenseHandler.ConfirmDelete)
 	auth.POST("/expenses/:id/cancel-delete", expenseHandler.CancelDelete)
+	auth.POST("/expenses/:id/actual", expenseHandler.SetActualAmount)
+	auth.GET("/expenses/:id/history", expenseHandler.History)
+	auth.POST("/expenses/:id/revisions", expenseHandler.ProposeChange)
+	auth.POST("/expenses/:id/revisions/:rev/approve", expenseHandler.ApproveChange)
+	auth.POST("/expenses/:id/revisions/:rev/reject", expenseHandler.RejectChange)
 
 	// Sabit/Taksitli Giderler
 	auth.GET("/recurring", recurringHandler.List)
@@ -130,12 +176,41 @@ func main() {
 	auth.DELETE("/recurring/:id", recurringHandler.Delete)
 	auth.POST("/recurring/:id/approve", recurringHandler.Approve)
 	auth.POST("/recurring/:id/reject", recurringHandler.Reject)
+	auth.GET("/recurring/:id/revisions", recurringHandler.Revisions)
+	auth.GET("/recurring/:id/schedule", recurringHandler.Schedule)
+	auth.POST("/recurring/:id/payoff", recurringHandler.Payoff)
+	auth.POST("/recurring/:id/restructure", recurringHandler.Restructure)
+	auth.POST("/recurring/:id/pause", recurringHandler.Pause)
+	auth.POST("/recurring/:id/resume", recurringHandler.Resume)
+	auth.GET("/recurring/:id/pauses", recurringHandler.Pauses)
+	auth.GET("/recurring/:id/skips", recurringHandler.Skips)
+	auth.POST("/recurring/:id/skips", recurringHandler.AddSkip)
+	auth.DELETE("/recurring/:id/skips/:skip", recurringHandler.DeleteSkip)
+	auth.GET("/recurring/:id/upcoming", recurringHandler.Upcoming)
+	auth.GET("/forecast", recurringHandler.Forecast)
+	auth.POST("/recurring/:id/revisions/:rev/approve", recurringHandler.ApproveRevision)
+	auth.POST("/recurring/:id/revisions/:rev/reject", recurringHandler.RejectRevision)
 
 	// Özet & Hesaplaşma
 	auth.GET("/summary", summaryHandler.GetSummary)
 	auth.GET("/payments", settlementHandler.ListPayments)
 	auth.POST("/payments", settlementHandler.AddPayment)
 	auth.DELETE("/payments/:id", settlementHandler.DeletePayment)
+	auth.POST("/payments/:id/confirm", settlementHandler.ConfirmPayment)
+	auth.POST("/payments/:id/dispute", settlementHandler.DisputePayment)
+	auth.POST("/payments/:id/confirm-delete", settlementHandler.ConfirmDeletePayment)
+	auth.POST("/payments/:id/cancel-delete", settlementHandler.CancelDeletePayment)
+	auth.GET("/balance", settlementHandler.GetBalance)
+	auth.POST("/balance/payments", settlementHandler.AddBalancePayment)
+
+	// Dönem kapatma
+	auth.GET("/periods", periodHandler.List)
+	auth.POST("/periods/:year/:month/close", periodHandler.Close)
+	auth.POST("/periods/:year/:month/reopen", periodHandler.Reopen)
+	auth.POST("/periods/:year/:month/cancel", periodHandler.CancelRequest)
+
+	// İşlem geçmişi
+	auth.GET("/audit", auditHandler.List)
 
 	// Graceful shutdown
 	go func() {
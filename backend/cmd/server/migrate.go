@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/caner/home-gider/internal/database"
+	"gorm.io/gorm"
+)
+
+// runMigrate "server migrate up|down [n]|status" komutunu çalıştırır
+func runMigrate(db *gorm.DB, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Kullanım: server migrate up|down [adım]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		if err := database.MigrateUp(db); err != nil {
+			log.Fatalf("Migration hatası: %v", err)
+		}
+		log.Println("Tüm migration'lar uygulandı")
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				log.Fatalf("Geçersiz adım sayısı: %s", args[1])
+			}
+			steps = n
+		}
+		if err := database.MigrateDown(db, steps); err != nil {
+			log.Fatalf("Migration geri alma hatası: %v", err)
+		}
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			log.Fatalf("Migration durumu okunamadı: %v", err)
+		}
+		for _, s := range states {
+			status := "bekliyor"
+			if s.Applied {
+				status = "uygulandı " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, status)
+		}
+	default:
+		log.Fatalf("Bilinmeyen migrate komutu: %s", args[0])
+	}
+}
@@ -0,0 +1,32 @@
+package database
+
+import "gorm.io/gorm"
+
+// WithAdvisoryLock tek bir bağlantı üzerinde Postgres advisory lock alır ve fn bitince bırakır.
+// fn'e verilen bağlantı lock'u tutan bağlantıdır; işlemler onun üzerinden yapılmalı.
+func WithAdvisoryLock(db *gorm.DB, key int64, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", key).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+		return fn(conn)
+	})
+}
+
+// TryAdvisoryLock lock'u beklemeden almayı dener. Lock başka bir oturumdaysa fn çalıştırılmaz
+// ve false döner; böylece aynı iş birden fazla replikada aynı anda çalışmaz.
+func TryAdvisoryLock(db *gorm.DB, key int64, fn func(conn *gorm.DB) error) (bool, error) {
+	acquired := false
+	err := db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+		return fn(conn)
+	})
+	return acquired, err
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS expenses;
+DROP TABLE IF EXISTS recurring_expenses;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS users;
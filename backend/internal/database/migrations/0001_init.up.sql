@@ -0,0 +1,78 @@
+-- Başlangıç şeması: daha önce AutoMigrate ile oluşturulan tablolar.
+-- IF NOT EXISTS sayesinde AutoMigrate ile kurulmuş mevcut veritabanlarında da güvenle çalışır.
+
+CREATE TABLE IF NOT EXISTS users (
+    id                   BIGSERIAL PRIMARY KEY,
+    username             VARCHAR(50)  NOT NULL,
+    password_hash        VARCHAR(255) NOT NULL,
+    display_name         VARCHAR(100) NOT NULL,
+    is_admin             BOOLEAN DEFAULT false,
+    must_change_password BOOLEAN DEFAULT true,
+    created_at           TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id   BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    icon VARCHAR(50)
+);
+
+CREATE TABLE IF NOT EXISTS recurring_expenses (
+    id                     BIGSERIAL PRIMARY KEY,
+    created_by             BIGINT NOT NULL,
+    category_id            BIGINT NOT NULL,
+    description            VARCHAR(255) NOT NULL,
+    amount                 DECIMAL(10,2) NOT NULL,
+    total_amount           DECIMAL(10,2),
+    type                   VARCHAR(20) NOT NULL,
+    installment_count      BIGINT,
+    installments_remaining BIGINT,
+    is_shared              BOOLEAN DEFAULT true,
+    split_ratio            DECIMAL(5,2) DEFAULT 50,
+    is_active              BOOLEAN DEFAULT true,
+    status                 VARCHAR(20) DEFAULT 'pending',
+    approved_by            BIGINT,
+    created_at             TIMESTAMPTZ,
+    CONSTRAINT fk_recurring_expenses_creator FOREIGN KEY (created_by) REFERENCES users (id),
+    CONSTRAINT fk_recurring_expenses_category FOREIGN KEY (category_id) REFERENCES categories (id),
+    CONSTRAINT fk_recurring_expenses_approver FOREIGN KEY (approved_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS expenses (
+    id                   BIGSERIAL PRIMARY KEY,
+    created_by           BIGINT NOT NULL,
+    category_id          BIGINT NOT NULL,
+    description          VARCHAR(255) NOT NULL,
+    amount               DECIMAL(10,2) NOT NULL,
+    expense_date         DATE NOT NULL,
+    expense_month        BIGINT NOT NULL,
+    expense_year         BIGINT NOT NULL,
+    is_shared            BOOLEAN DEFAULT true,
+    split_ratio          DECIMAL(5,2) DEFAULT 50,
+    is_installment       BOOLEAN DEFAULT false,
+    installment_no       BIGINT,
+    installment_total    BIGINT,
+    recurring_expense_id BIGINT,
+    status               VARCHAR(20) DEFAULT 'pending',
+    approved_by          BIGINT,
+    approved_at          TIMESTAMPTZ,
+    delete_requested_by  BIGINT,
+    created_at           TIMESTAMPTZ,
+    CONSTRAINT fk_expenses_creator FOREIGN KEY (created_by) REFERENCES users (id),
+    CONSTRAINT fk_expenses_category FOREIGN KEY (category_id) REFERENCES categories (id),
+    CONSTRAINT fk_expenses_approver FOREIGN KEY (approved_by) REFERENCES users (id),
+    CONSTRAINT fk_expenses_delete_requester FOREIGN KEY (delete_requested_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id         BIGSERIAL PRIMARY KEY,
+    month      BIGINT NOT NULL,
+    year       BIGINT NOT NULL,
+    payer_id   BIGINT NOT NULL,
+    payee_id   BIGINT NOT NULL,
+    amount     DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMPTZ,
+    CONSTRAINT fk_payments_payer FOREIGN KEY (payer_id) REFERENCES users (id),
+    CONSTRAINT fk_payments_payee FOREIGN KEY (payee_id) REFERENCES users (id)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE expenses ALTER COLUMN amount TYPE DECIMAL(10,2) USING amount / 100.0;
+ALTER TABLE recurring_expenses ALTER COLUMN amount TYPE DECIMAL(10,2) USING amount / 100.0;
+ALTER TABLE recurring_expenses ALTER COLUMN total_amount TYPE DECIMAL(10,2) USING total_amount / 100.0;
+ALTER TABLE payments ALTER COLUMN amount TYPE DECIMAL(10,2) USING amount / 100.0;
@@ -0,0 +1,5 @@
+-- Tutarlar kuruş cinsinden tam sayı olarak saklanır (models.Money)
+ALTER TABLE expenses ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100);
+ALTER TABLE recurring_expenses ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100);
+ALTER TABLE recurring_expenses ALTER COLUMN total_amount TYPE BIGINT USING ROUND(total_amount * 100);
+ALTER TABLE payments ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100);
@@ -0,0 +1,5 @@
+ALTER TABLE payments DROP COLUMN household_id;
+ALTER TABLE recurring_expenses DROP COLUMN household_id;
+ALTER TABLE expenses DROP COLUMN household_id;
+DROP TABLE household_members;
+DROP TABLE households;
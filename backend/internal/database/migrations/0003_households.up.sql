@@ -0,0 +1,43 @@
+CREATE TABLE households (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ
+);
+
+CREATE TABLE household_members (
+    household_id BIGINT NOT NULL,
+    user_id      BIGINT NOT NULL,
+    role         VARCHAR(20) NOT NULL DEFAULT 'member',
+    created_at   TIMESTAMPTZ,
+    PRIMARY KEY (household_id, user_id),
+    CONSTRAINT fk_household_members_household FOREIGN KEY (household_id) REFERENCES households (id) ON DELETE CASCADE,
+    CONSTRAINT fk_household_members_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_household_members_user_id ON household_members (user_id);
+
+-- Mevcut kurulum: tüm kullanıcılar ve kayıtlar varsayılan haneye taşınır
+INSERT INTO households (name, created_at)
+SELECT 'Ev', NOW() WHERE EXISTS (SELECT 1 FROM users);
+
+INSERT INTO household_members (household_id, user_id, role, created_at)
+SELECT (SELECT MIN(id) FROM households), id,
+       CASE WHEN is_admin THEN 'admin' ELSE 'member' END, NOW()
+FROM users;
+
+ALTER TABLE expenses ADD COLUMN household_id BIGINT;
+UPDATE expenses SET household_id = (SELECT MIN(id) FROM households);
+ALTER TABLE expenses ALTER COLUMN household_id SET NOT NULL;
+ALTER TABLE expenses ADD CONSTRAINT fk_expenses_household FOREIGN KEY (household_id) REFERENCES households (id);
+CREATE INDEX idx_expenses_household_id ON expenses (household_id);
+
+ALTER TABLE recurring_expenses ADD COLUMN household_id BIGINT;
+UPDATE recurring_expenses SET household_id = (SELECT MIN(id) FROM households);
+ALTER TABLE recurring_expenses ALTER COLUMN household_id SET NOT NULL;
+ALTER TABLE recurring_expenses ADD CONSTRAINT fk_recurring_expenses_household FOREIGN KEY (household_id) REFERENCES households (id);
+CREATE INDEX idx_recurring_expenses_household_id ON recurring_expenses (household_id);
+
+ALTER TABLE payments ADD COLUMN household_id BIGINT;
+UPDATE payments SET household_id = (SELECT MIN(id) FROM households);
+ALTER TABLE payments ALTER COLUMN household_id SET NOT NULL;
+ALTER TABLE payments ADD CONSTRAINT fk_payments_household FOREIGN KEY (household_id) REFERENCES households (id);
+CREATE INDEX idx_payments_household_id ON payments (household_id);
@@ -0,0 +1,16 @@
+CREATE TABLE invites (
+    id           BIGSERIAL PRIMARY KEY,
+    household_id BIGINT NOT NULL,
+    created_by   BIGINT NOT NULL,
+    token_hash   VARCHAR(64) NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    used_by      BIGINT,
+    used_at      TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ,
+    CONSTRAINT fk_invites_household FOREIGN KEY (household_id) REFERENCES households (id) ON DELETE CASCADE,
+    CONSTRAINT fk_invites_creator FOREIGN KEY (created_by) REFERENCES users (id),
+    CONSTRAINT fk_invites_user FOREIGN KEY (used_by) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX idx_invites_token_hash ON invites (token_hash);
+CREATE INDEX idx_invites_household_id ON invites (household_id);
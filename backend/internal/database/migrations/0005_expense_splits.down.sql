@@ -0,0 +1,12 @@
+ALTER TABLE expenses ADD COLUMN split_ratio DECIMAL(5,2) DEFAULT 50;
+
+UPDATE expenses e
+SET split_ratio = COALESCE((
+    SELECT ROUND(s.amount * 100.0 / NULLIF(e.amount, 0), 2)
+    FROM expense_splits s
+    WHERE s.expense_id = e.id AND s.user_id = e.created_by
+), 50)
+WHERE e.is_shared;
+
+DROP TABLE expense_splits;
+ALTER TABLE expenses DROP COLUMN split_mode;
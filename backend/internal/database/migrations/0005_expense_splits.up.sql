@@ -0,0 +1,41 @@
+ALTER TABLE expenses ADD COLUMN split_mode VARCHAR(20) NOT NULL DEFAULT 'equal';
+
+CREATE TABLE expense_splits (
+    id         BIGSERIAL PRIMARY KEY,
+    expense_id BIGINT NOT NULL,
+    user_id    BIGINT NOT NULL,
+    shares     BIGINT,
+    percentage DECIMAL(5,2),
+    amount     BIGINT NOT NULL,
+    CONSTRAINT fk_expenses_splits FOREIGN KEY (expense_id) REFERENCES expenses (id) ON DELETE CASCADE,
+    CONSTRAINT fk_expense_splits_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_expense_splits_expense_id ON expense_splits (expense_id);
+
+-- Kişisel giderler: tutarın tamamı oluşturana
+INSERT INTO expense_splits (expense_id, user_id, amount)
+SELECT id, created_by, amount FROM expenses WHERE NOT is_shared;
+
+-- Paylaşımlı giderler: oluşturan split_ratio kadar, kalan diğer üyelere kuruşu kuruşuna eşit
+UPDATE expenses SET split_mode = 'percentage' WHERE is_shared;
+
+INSERT INTO expense_splits (expense_id, user_id, percentage, amount)
+SELECT id, created_by, split_ratio, ROUND(amount * split_ratio / 100)::BIGINT
+FROM expenses WHERE is_shared;
+
+INSERT INTO expense_splits (expense_id, user_id, percentage, amount)
+SELECT o.expense_id, o.user_id,
+       ROUND((100 - o.split_ratio) / o.cnt, 2),
+       o.rest / o.cnt + CASE WHEN o.rn <= o.rest % o.cnt THEN 1 ELSE 0 END
+FROM (
+    SELECT e.id AS expense_id, hm.user_id, e.split_ratio,
+           e.amount - ROUND(e.amount * e.split_ratio / 100)::BIGINT AS rest,
+           COUNT(*) OVER (PARTITION BY e.id) AS cnt,
+           ROW_NUMBER() OVER (PARTITION BY e.id ORDER BY hm.user_id) AS rn
+    FROM expenses e
+    JOIN household_members hm ON hm.household_id = e.household_id AND hm.user_id <> e.created_by
+    JOIN users u ON u.id = hm.user_id AND NOT u.is_admin
+    WHERE e.is_shared
+) o;
+
+ALTER TABLE expenses DROP COLUMN split_ratio;
@@ -0,0 +1,2 @@
+ALTER TABLE recurring_expenses DROP COLUMN paid_by;
+ALTER TABLE expenses DROP COLUMN paid_by;
@@ -0,0 +1,10 @@
+-- Ödeyen kişi, kaydı girenden ayrı tutulur; mevcut kayıtlarda oluşturan ödemiş sayılır
+ALTER TABLE expenses ADD COLUMN paid_by BIGINT;
+UPDATE expenses SET paid_by = created_by;
+ALTER TABLE expenses ALTER COLUMN paid_by SET NOT NULL;
+ALTER TABLE expenses ADD CONSTRAINT fk_expenses_payer FOREIGN KEY (paid_by) REFERENCES users (id);
+
+ALTER TABLE recurring_expenses ADD COLUMN paid_by BIGINT;
+UPDATE recurring_expenses SET paid_by = created_by;
+ALTER TABLE recurring_expenses ALTER COLUMN paid_by SET NOT NULL;
+ALTER TABLE recurring_expenses ADD CONSTRAINT fk_recurring_expenses_payer FOREIGN KEY (paid_by) REFERENCES users (id);
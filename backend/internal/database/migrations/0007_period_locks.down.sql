@@ -0,0 +1 @@
+DROP TABLE period_locks;
@@ -0,0 +1,16 @@
+CREATE TABLE period_locks (
+    id           BIGSERIAL PRIMARY KEY,
+    household_id BIGINT NOT NULL,
+    month        BIGINT NOT NULL,
+    year         BIGINT NOT NULL,
+    status       VARCHAR(20) NOT NULL,
+    requested_by BIGINT,
+    closed_by    BIGINT,
+    closed_at    TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ,
+    CONSTRAINT fk_period_locks_household FOREIGN KEY (household_id) REFERENCES households (id) ON DELETE CASCADE,
+    CONSTRAINT fk_period_locks_requester FOREIGN KEY (requested_by) REFERENCES users (id),
+    CONSTRAINT fk_period_locks_closer FOREIGN KEY (closed_by) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX idx_period_locks_period ON period_locks (household_id, month, year);
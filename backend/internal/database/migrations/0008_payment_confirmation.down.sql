@@ -0,0 +1,3 @@
+ALTER TABLE payments DROP COLUMN delete_requested_by;
+ALTER TABLE payments DROP COLUMN confirmed_at;
+ALTER TABLE payments DROP COLUMN status;
@@ -0,0 +1,7 @@
+ALTER TABLE payments ADD COLUMN status VARCHAR(20) DEFAULT 'pending';
+ALTER TABLE payments ADD COLUMN confirmed_at TIMESTAMPTZ;
+ALTER TABLE payments ADD COLUMN delete_requested_by BIGINT;
+ALTER TABLE payments ADD CONSTRAINT fk_payments_delete_requester FOREIGN KEY (delete_requested_by) REFERENCES users (id);
+
+-- Mevcut ödemeler zaten hesaplaşmaya girmişti, onaylı sayılır
+UPDATE payments SET status = 'approved', confirmed_at = created_at;
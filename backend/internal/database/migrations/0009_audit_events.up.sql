@@ -0,0 +1,25 @@
+CREATE TABLE audit_events (
+    id           BIGSERIAL PRIMARY KEY,
+    household_id BIGINT,
+    actor_id     BIGINT,
+    entity_type  VARCHAR(30) NOT NULL,
+    entity_id    BIGINT NOT NULL,
+    action       VARCHAR(30) NOT NULL,
+    changes      JSONB NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMPTZ,
+    CONSTRAINT fk_audit_events_household FOREIGN KEY (household_id) REFERENCES households (id),
+    CONSTRAINT fk_audit_events_actor FOREIGN KEY (actor_id) REFERENCES users (id)
+);
+CREATE INDEX idx_audit_events_household_id ON audit_events (household_id, created_at DESC);
+CREATE INDEX idx_audit_events_entity ON audit_events (entity_type, entity_id);
+
+-- Kayıtlar sadece eklenebilir
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events sadece eklenebilir';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
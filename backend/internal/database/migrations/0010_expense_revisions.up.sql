@@ -0,0 +1,25 @@
+CREATE TABLE expense_revisions (
+    id            BIGSERIAL PRIMARY KEY,
+    expense_id    BIGINT NOT NULL,
+    version       BIGINT NOT NULL,
+    proposed_by   BIGINT NOT NULL,
+    status        VARCHAR(20) NOT NULL,
+    category_id   BIGINT NOT NULL,
+    description   VARCHAR(255) NOT NULL,
+    amount        BIGINT NOT NULL,
+    paid_by       BIGINT NOT NULL,
+    expense_month BIGINT NOT NULL,
+    expense_year  BIGINT NOT NULL,
+    is_shared     BOOLEAN,
+    split_mode    VARCHAR(20) NOT NULL,
+    splits        JSONB NOT NULL DEFAULT '[]',
+    reviewed_by   BIGINT,
+    reviewed_at   TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ,
+    CONSTRAINT fk_expense_revisions_expense FOREIGN KEY (expense_id) REFERENCES expenses (id) ON DELETE CASCADE,
+    CONSTRAINT fk_expense_revisions_proposer FOREIGN KEY (proposed_by) REFERENCES users (id),
+    CONSTRAINT fk_expense_revisions_reviewer FOREIGN KEY (reviewed_by) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX idx_expense_revisions_version ON expense_revisions (expense_id, version);
+-- Bir gider için aynı anda tek bekleyen öneri olabilir
+CREATE UNIQUE INDEX idx_expense_revisions_pending ON expense_revisions (expense_id) WHERE status = 'pending';
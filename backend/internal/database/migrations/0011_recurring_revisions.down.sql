@@ -0,0 +1,3 @@
+ALTER TABLE expenses DROP COLUMN IF EXISTS recurring_revision_id;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS current_revision_id;
+DROP TABLE IF EXISTS recurring_revisions;
@@ -0,0 +1,41 @@
+CREATE TABLE recurring_revisions (
+    id                   BIGSERIAL PRIMARY KEY,
+    recurring_expense_id BIGINT NOT NULL,
+    version              BIGINT NOT NULL,
+    proposed_by          BIGINT NOT NULL,
+    status               VARCHAR(20) NOT NULL,
+    category_id          BIGINT NOT NULL,
+    description          VARCHAR(255) NOT NULL,
+    amount               BIGINT NOT NULL,
+    total_amount         BIGINT,
+    paid_by              BIGINT NOT NULL,
+    is_shared            BOOLEAN,
+    split_ratio          DECIMAL(5,2),
+    reviewed_by          BIGINT,
+    reviewed_at          TIMESTAMPTZ,
+    created_at           TIMESTAMPTZ,
+    CONSTRAINT fk_recurring_revisions_template FOREIGN KEY (recurring_expense_id) REFERENCES recurring_expenses (id) ON DELETE CASCADE,
+    CONSTRAINT fk_recurring_revisions_proposer FOREIGN KEY (proposed_by) REFERENCES users (id),
+    CONSTRAINT fk_recurring_revisions_reviewer FOREIGN KEY (reviewed_by) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX idx_recurring_revisions_version ON recurring_revisions (recurring_expense_id, version);
+CREATE UNIQUE INDEX idx_recurring_revisions_pending ON recurring_revisions (recurring_expense_id) WHERE status = 'pending';
+
+ALTER TABLE recurring_expenses ADD COLUMN current_revision_id BIGINT;
+ALTER TABLE recurring_expenses ADD CONSTRAINT fk_recurring_expenses_current_revision FOREIGN KEY (current_revision_id) REFERENCES recurring_revisions (id);
+
+ALTER TABLE expenses ADD COLUMN recurring_revision_id BIGINT;
+ALTER TABLE expenses ADD CONSTRAINT fk_expenses_recurring_revision FOREIGN KEY (recurring_revision_id) REFERENCES recurring_revisions (id);
+
+-- Mevcut her şablonun bugünkü hâli ilk sürüm olur
+INSERT INTO recurring_revisions (recurring_expense_id, version, proposed_by, status, category_id, description,
+                                 amount, total_amount, paid_by, is_shared, split_ratio, reviewed_by, created_at)
+SELECT id, 1, created_by, status, category_id, description, amount, total_amount, paid_by, is_shared, split_ratio,
+       approved_by, created_at
+FROM recurring_expenses;
+
+UPDATE recurring_expenses r SET current_revision_id = v.id
+FROM recurring_revisions v WHERE v.recurring_expense_id = r.id AND v.version = 1;
+
+UPDATE expenses e SET recurring_revision_id = r.current_revision_id
+FROM recurring_expenses r WHERE e.recurring_expense_id = r.id;
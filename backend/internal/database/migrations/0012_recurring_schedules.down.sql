@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_expenses_occurrence;
+ALTER TABLE expenses DROP COLUMN IF EXISTS occurrence_date;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS end_date;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS start_date;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS day_of_month;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS interval_count;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS frequency;
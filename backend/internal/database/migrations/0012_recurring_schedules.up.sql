@@ -0,0 +1,15 @@
+ALTER TABLE recurring_expenses ADD COLUMN frequency VARCHAR(20) NOT NULL DEFAULT 'monthly';
+ALTER TABLE recurring_expenses ADD COLUMN interval_count BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE recurring_expenses ADD COLUMN day_of_month BIGINT;
+ALTER TABLE recurring_expenses ADD COLUMN start_date DATE;
+ALTER TABLE recurring_expenses ADD COLUMN end_date DATE;
+
+-- Eski şablonlar ayda bir, ayın ilk çalışmasında üretiliyordu: ayın 1'i olarak sabitlenir
+UPDATE recurring_expenses
+SET day_of_month = 1, start_date = date_trunc('month', created_at)::date;
+ALTER TABLE recurring_expenses ALTER COLUMN start_date SET NOT NULL;
+
+ALTER TABLE expenses ADD COLUMN occurrence_date DATE;
+UPDATE expenses SET occurrence_date = make_date(expense_year::int, expense_month::int, 1)
+WHERE recurring_expense_id IS NOT NULL;
+CREATE INDEX idx_expenses_occurrence ON expenses (recurring_expense_id, occurrence_date);
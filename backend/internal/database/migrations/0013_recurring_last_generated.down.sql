@@ -0,0 +1 @@
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS last_generated_period;
@@ -0,0 +1,11 @@
+ALTER TABLE recurring_expenses ADD COLUMN last_generated_period DATE;
+
+-- Şimdiye kadar üretilmiş son tekrar, kaldığı yer kabul edilir
+UPDATE recurring_expenses r SET last_generated_period = e.last_date
+FROM (
+    SELECT recurring_expense_id, MAX(occurrence_date) AS last_date
+    FROM expenses
+    WHERE recurring_expense_id IS NOT NULL
+    GROUP BY recurring_expense_id
+) e
+WHERE e.recurring_expense_id = r.id;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_expenses_occurrence;
+CREATE INDEX idx_expenses_occurrence ON expenses (recurring_expense_id, occurrence_date);
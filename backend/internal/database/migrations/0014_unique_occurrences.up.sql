@@ -0,0 +1,29 @@
+-- Aynı anda çalışan replikaların ürettiği mükerrer tekrarlar temizlenir. Her vade için
+-- onaylanmış kayıt, o yoksa ilk kayıt kalır; silinenler sistem adına denetim kaydına yazılır.
+CREATE TEMPORARY TABLE duplicate_occurrences ON COMMIT DROP AS
+SELECT id FROM (
+    SELECT id, ROW_NUMBER() OVER (
+        PARTITION BY recurring_expense_id, occurrence_date
+        ORDER BY (status IS NOT DISTINCT FROM 'approved') DESC, id
+    ) AS pos
+    FROM expenses
+    WHERE recurring_expense_id IS NOT NULL AND occurrence_date IS NOT NULL
+) ranked
+WHERE pos > 1;
+
+INSERT INTO audit_events (household_id, actor_id, entity_type, entity_id, action, changes, created_at)
+SELECT e.household_id, NULL, 'expense', e.id, 'delete',
+       (SELECT jsonb_object_agg(f.key, jsonb_build_object('before', f.value, 'after', NULL))
+        FROM jsonb_each(to_jsonb(e)) f
+        WHERE f.value <> 'null'::jsonb),
+       NOW()
+FROM expenses e
+WHERE e.id IN (SELECT id FROM duplicate_occurrences);
+
+DELETE FROM expenses WHERE id IN (SELECT id FROM duplicate_occurrences);
+
+-- Tekrar başına tek gider. Ay/yıl yerine vade tarihi kullanılır; haftalık şablonlar
+-- aynı ay içinde birden fazla gider üretir.
+DROP INDEX IF EXISTS idx_expenses_occurrence;
+CREATE UNIQUE INDEX idx_expenses_occurrence ON expenses (recurring_expense_id, occurrence_date)
+WHERE recurring_expense_id IS NOT NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE job_runs (
+    id            BIGSERIAL PRIMARY KEY,
+    job           VARCHAR(50) NOT NULL,
+    trigger       VARCHAR(20) NOT NULL,
+    triggered_by  BIGINT,
+    status        VARCHAR(20) NOT NULL,
+    error         TEXT,
+    items_created BIGINT NOT NULL DEFAULT 0,
+    started_at    TIMESTAMPTZ NOT NULL,
+    finished_at   TIMESTAMPTZ,
+    CONSTRAINT fk_job_runs_user FOREIGN KEY (triggered_by) REFERENCES users (id)
+);
+CREATE INDEX idx_job_runs_job ON job_runs (job, started_at DESC);
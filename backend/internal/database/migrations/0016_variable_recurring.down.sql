@@ -0,0 +1,2 @@
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS estimate_window;
+ALTER TABLE expenses DROP COLUMN IF EXISTS is_estimate;
@@ -0,0 +1,2 @@
+ALTER TABLE expenses ADD COLUMN is_estimate BOOLEAN DEFAULT false;
+ALTER TABLE recurring_expenses ADD COLUMN estimate_window BIGINT NOT NULL DEFAULT 3;
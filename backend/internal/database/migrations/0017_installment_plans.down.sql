@@ -0,0 +1,5 @@
+ALTER TABLE recurring_expenses DROP CONSTRAINT IF EXISTS fk_recurring_expenses_payoff;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS payoff_expense_id;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS schedule_offset;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS interest_rate;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS principal;
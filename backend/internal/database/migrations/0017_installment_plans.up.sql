@@ -0,0 +1,10 @@
+ALTER TABLE recurring_expenses ADD COLUMN principal BIGINT;
+ALTER TABLE recurring_expenses ADD COLUMN interest_rate DECIMAL(7,4) NOT NULL DEFAULT 0;
+ALTER TABLE recurring_expenses ADD COLUMN schedule_offset BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE recurring_expenses ADD COLUMN payoff_expense_id BIGINT;
+ALTER TABLE recurring_expenses ADD CONSTRAINT fk_recurring_expenses_payoff FOREIGN KEY (payoff_expense_id) REFERENCES expenses (id) ON DELETE SET NULL;
+
+-- Mevcut taksitler faizsiz kabul edilir: anapara ve toplam = taksit tutarı × taksit sayısı
+UPDATE recurring_expenses
+SET principal = amount * installment_count, total_amount = amount * installment_count
+WHERE type = 'installment' AND installment_count IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS paused_at;
+DROP TABLE IF EXISTS recurring_skips;
+DROP TABLE IF EXISTS recurring_pauses;
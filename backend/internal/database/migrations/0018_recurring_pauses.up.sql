@@ -0,0 +1,32 @@
+CREATE TABLE recurring_pauses (
+    id                   BIGSERIAL PRIMARY KEY,
+    recurring_expense_id BIGINT NOT NULL,
+    paused_at            DATE NOT NULL,
+    paused_by            BIGINT NOT NULL,
+    reason               VARCHAR(255) NOT NULL,
+    resumed_at           DATE,
+    resumed_by           BIGINT,
+    resume_reason        VARCHAR(255),
+    created_at           TIMESTAMPTZ,
+    CONSTRAINT fk_recurring_pauses_template FOREIGN KEY (recurring_expense_id) REFERENCES recurring_expenses (id) ON DELETE CASCADE,
+    CONSTRAINT fk_recurring_pauses_pauser FOREIGN KEY (paused_by) REFERENCES users (id),
+    CONSTRAINT fk_recurring_pauses_resumer FOREIGN KEY (resumed_by) REFERENCES users (id)
+);
+CREATE INDEX idx_recurring_pauses_recurring_expense_id ON recurring_pauses (recurring_expense_id);
+-- Şablon başına tek açık duraklatma
+CREATE UNIQUE INDEX idx_recurring_pauses_open ON recurring_pauses (recurring_expense_id) WHERE resumed_at IS NULL;
+
+CREATE TABLE recurring_skips (
+    id                   BIGSERIAL PRIMARY KEY,
+    recurring_expense_id BIGINT NOT NULL,
+    skip_month           BIGINT NOT NULL,
+    skip_year            BIGINT NOT NULL,
+    reason               VARCHAR(255) NOT NULL,
+    created_by           BIGINT NOT NULL,
+    created_at           TIMESTAMPTZ,
+    CONSTRAINT fk_recurring_skips_template FOREIGN KEY (recurring_expense_id) REFERENCES recurring_expenses (id) ON DELETE CASCADE,
+    CONSTRAINT fk_recurring_skips_creator FOREIGN KEY (created_by) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX idx_recurring_skips_period ON recurring_skips (recurring_expense_id, skip_year, skip_month);
+
+ALTER TABLE recurring_expenses ADD COLUMN paused_at DATE;
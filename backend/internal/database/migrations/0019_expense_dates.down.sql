@@ -0,0 +1,4 @@
+ALTER TABLE expense_revisions DROP COLUMN IF EXISTS expense_date;
+DROP INDEX IF EXISTS idx_expenses_household_date;
+
+UPDATE expenses SET expense_date = make_date(expense_year::int, expense_month::int, 1);
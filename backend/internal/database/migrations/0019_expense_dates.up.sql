@@ -0,0 +1,19 @@
+-- Elle girilmiş giderlerde tarih ayın ilk günüydü. Gider ait olduğu ay içinde girildiyse
+-- giriliş günü gerçek tarih kabul edilir; geçmiş aylara sonradan girilenler ayın ilk gününde kalır.
+UPDATE expenses
+SET expense_date = created_at::date
+WHERE recurring_expense_id IS NULL
+  AND expense_date = make_date(expense_year::int, expense_month::int, 1)
+  AND EXTRACT(YEAR FROM created_at) = expense_year
+  AND EXTRACT(MONTH FROM created_at) = expense_month;
+
+-- Şablondan üretilen giderlerin tarihi vade tarihidir
+UPDATE expenses
+SET expense_date = occurrence_date
+WHERE occurrence_date IS NOT NULL AND expense_date <> occurrence_date;
+
+CREATE INDEX idx_expenses_household_date ON expenses (household_id, expense_date);
+
+ALTER TABLE expense_revisions ADD COLUMN expense_date DATE;
+UPDATE expense_revisions r SET expense_date = e.expense_date FROM expenses e WHERE e.id = r.expense_id;
+ALTER TABLE expense_revisions ALTER COLUMN expense_date SET NOT NULL;
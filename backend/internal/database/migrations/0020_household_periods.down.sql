@@ -0,0 +1,2 @@
+ALTER TABLE households DROP COLUMN IF EXISTS timezone;
+ALTER TABLE households DROP COLUMN IF EXISTS period_start_day;
@@ -0,0 +1,2 @@
+ALTER TABLE households ADD COLUMN period_start_day BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE households ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
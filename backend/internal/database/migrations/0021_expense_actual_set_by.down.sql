@@ -0,0 +1 @@
+ALTER TABLE expenses DROP COLUMN IF EXISTS actual_set_by;
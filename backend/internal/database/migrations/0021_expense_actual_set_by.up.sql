@@ -0,0 +1,10 @@
+-- Tahmini faturanın gerçek tutarını giren kişi; o kişi faturayı onaylayamaz. Onay bekleyen
+-- faturalarda gerçek tutarı yalnızca ödeyen girebildiği için ödeyen yazılır.
+ALTER TABLE expenses ADD COLUMN actual_set_by BIGINT;
+UPDATE expenses SET actual_set_by = expenses.paid_by
+FROM recurring_expenses
+WHERE recurring_expenses.id = expenses.recurring_expense_id
+  AND recurring_expenses.type = 'variable'
+  AND expenses.status = 'pending'
+  AND expenses.is_estimate = false;
+ALTER TABLE expenses ADD CONSTRAINT fk_expenses_actual_setter FOREIGN KEY (actual_set_by) REFERENCES users (id);
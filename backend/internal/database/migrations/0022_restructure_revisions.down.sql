@@ -0,0 +1,3 @@
+ALTER TABLE recurring_revisions DROP COLUMN IF EXISTS installments_remaining;
+ALTER TABLE recurring_revisions DROP COLUMN IF EXISTS interest_rate;
+ALTER TABLE recurring_revisions DROP COLUMN IF EXISTS principal;
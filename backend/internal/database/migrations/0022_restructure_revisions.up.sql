@@ -0,0 +1,4 @@
+-- Taksit planının yeniden yapılandırılması da diğer üyenin onayını bekleyen bir sürümdür
+ALTER TABLE recurring_revisions ADD COLUMN principal BIGINT;
+ALTER TABLE recurring_revisions ADD COLUMN interest_rate DECIMAL(7,4);
+ALTER TABLE recurring_revisions ADD COLUMN installments_remaining BIGINT;
@@ -22,30 +22,42 @@ func seedUsers(db *gorm.DB) {
 
 	// Admin kullanıcı — ilk girişte şifre belirleyecek
 	adminHash, _ := bcrypt.GenerateFromPassword([]byte("temp"), bcrypt.DefaultCost)
-	db.Create(&models.User{
+	admin := models.User{
 		Username:           "admin",
 		PasswordHash:       string(adminHash),
 		DisplayName:        "Admin",
 		IsAdmin:            true,
 		MustChangePassword: true,
-	})
+	}
+	db.Create(&admin)
 
 	// CNR ve CNS — ilk girişte şifre belirleyecekler
 	// Geçici şifre: "temp" (sadece ilk giriş için)
 	tempHash, _ := bcrypt.GenerateFromPassword([]byte("temp"), bcrypt.DefaultCost)
-	db.Create(&models.User{
+	cnr := models.User{
 		Username:           "cnr",
 		PasswordHash:       string(tempHash),
 		DisplayName:        "CNR",
 		IsAdmin:            false,
 		MustChangePassword: true,
-	})
-	db.Create(&models.User{
+	}
+	db.Create(&cnr)
+	cns := models.User{
 		Username:           "cns",
 		PasswordHash:       string(tempHash),
 		DisplayName:        "CNS",
 		IsAdmin:            false,
 		MustChangePassword: true,
+	}
+	db.Create(&cns)
+
+	// Varsayılan hane — üç kullanıcı da üye
+	household := models.Household{Name: "Ev"}
+	db.Create(&household)
+	db.Create(&[]models.HouseholdMember{
+		{HouseholdID: household.ID, UserID: admin.ID, Role: models.RoleAdmin},
+		{HouseholdID: household.ID, UserID: cnr.ID, Role: models.RoleMember},
+		{HouseholdID: household.ID, UserID: cns.ID, Role: models.RoleMember},
 	})
 
 	log.Println("Kullanıcılar oluşturuldu (CNR, CNS, Admin)")
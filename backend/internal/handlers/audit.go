@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/caner/home-gider/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// auditFilter sorgu parametrelerini okur: entity_type, entity_id, actor_id, action,
+// from/to (YYYY-MM-DD, to dahil), before_id ve limit
+func auditFilter(c echo.Context) (services.AuditFilter, bool) {
+	filter := services.AuditFilter{
+		EntityType: c.QueryParam("entity_type"),
+		Action:     c.QueryParam("action"),
+	}
+	for param, dest := range map[string]*uint{
+		"entity_id": &filter.EntityID,
+		"actor_id":  &filter.ActorID,
+		"before_id": &filter.BeforeID,
+	} {
+		if v := c.QueryParam(param); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return filter, false
+			}
+			*dest = uint(id)
+		}
+	}
+	if v := c.QueryParam("from"); v != "" {
+		from, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, false
+		}
+		filter.From = &from
+	}
+	if v := c.QueryParam("to"); v != "" {
+		to, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, false
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, false
+		}
+		filter.Limit = limit
+	}
+	return filter, true
+}
+
+// Hanenin işlem geçmişi
+func (h *AuditHandler) List(c echo.Context) error {
+	filter, ok := auditFilter(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz filtre"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	events, err := h.service.List(householdID, filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Kayıtlar yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, events)
+}
+
+// Admin: hane dışı kayıtlar dahil tüm işlem geçmişi
+func (h *AuditHandler) ListAll(c echo.Context) error {
+	filter, ok := auditFilter(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz filtre"})
+	}
+
+	events, err := h.service.ListAll(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Kayıtlar yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, events)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caner/home-gider/internal/services"
@@ -48,6 +49,54 @@ func (h *AuthHandler) Login(c echo.Context) error {
 	})
 }
 
+type RegisterRequest struct {
+	InviteToken string `json:"invite_token"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Password    string `json:"password"`
+}
+
+// Davet koduyla kayıt ol — kullanıcı daveti gönderen haneye katılır
+func (h *AuthHandler) Register(c echo.Context) error {
+	var req RegisterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	if req.InviteToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Davet kodu gerekli"})
+	}
+	if len(req.Username) < 3 || len(req.Username) > 50 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Kullanıcı adı 3-50 karakter olmalı"})
+	}
+	if req.DisplayName == "" {
+		req.DisplayName = req.Username
+	}
+	if len(req.Password) < 4 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Şifre en az 4 karakter olmalı"})
+	}
+
+	user, token, err := h.service.Register(req.InviteToken, req.Username, req.DisplayName, req.Password)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	cookie := &http.Cookie{
+		Name:     "token",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   30 * 24 * 60 * 60,
+	}
+	c.SetCookie(cookie)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"user": user,
+	})
+}
+
 func (h *AuthHandler) Logout(c echo.Context) error {
 	cookie := &http.Cookie{
 		Name:     "token",
@@ -70,6 +119,39 @@ func (h *AuthHandler) Me(c echo.Context) error {
 	return c.JSON(http.StatusOK, user)
 }
 
+type SwitchHouseholdRequest struct {
+	HouseholdID uint `json:"household_id"`
+}
+
+// Aktif haneyi değiştir — yeni hane bilgisini taşıyan token üretilir
+func (h *AuthHandler) SwitchHousehold(c echo.Context) error {
+	var req SwitchHouseholdRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	user, token, err := h.service.SwitchHousehold(userID, req.HouseholdID)
+	if err != nil {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+
+	cookie := &http.Cookie{
+		Name:     "token",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   30 * 24 * 60 * 60,
+	}
+	c.SetCookie(cookie)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"user":         user,
+		"household_id": req.HouseholdID,
+	})
+}
+
 type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password"`
 }
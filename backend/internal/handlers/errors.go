@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/caner/home-gider/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// badRequest alan doğrulama hatalarını 422 ve alan listesiyle, diğer hataları 400 ile döner
+func badRequest(c echo.Context, err error) error {
+	var verr *services.ValidationError
+	if errors.As(err, &verr) {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":  "Geçersiz alanlar",
+			"fields": verr.Fields,
+		})
+	}
+	return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caner/home-gider/internal/models"
@@ -19,31 +21,147 @@ func NewExpenseHandler(service *services.ExpenseService) *ExpenseHandler {
 }
 
 type CreateExpenseRequest struct {
-	CategoryID uint    `json:"category_id"`
-	Description string `json:"description"`
-	Amount      float64 `json:"amount"`
-	Month       int     `json:"month"`
-	Year        int     `json:"year"`
-	IsShared    bool    `json:"is_shared"`
-	SplitRatio  float64 `json:"split_ratio"`
+	CategoryID  uint         `json:"category_id"`
+	Description string       `json:"description"`
+	Amount      models.Money `json:"amount"`
+	PaidBy      uint         `json:"paid_by"`
+	Date        string       `json:"date"`
+	Month       int          `json:"month"`
+	Year        int          `json:"year"`
+	IsShared    bool         `json:"is_shared"`
+	// split_mode, splits ve eski split_ratio alanları
+	services.SplitSpec
 }
 
-func (h *ExpenseHandler) List(c echo.Context) error {
-	now := time.Now()
-	month, _ := strconv.Atoi(c.QueryParam("month"))
-	year, _ := strconv.Atoi(c.QueryParam("year"))
-	if month == 0 {
-		month = int(now.Month())
+// Gider listesinin sayfa bilgisini taşıyan yanıt başlıkları
+const (
+	HeaderTotalCount  = "X-Total-Count"
+	HeaderTotalAmount = "X-Total-Amount"
+	HeaderNextCursor  = "X-Next-Cursor"
+)
+
+// expenseFilter sorgu parametrelerini okur: month/year ya da from/to (YYYY-MM-DD, iki uç dahil),
+// all, q, category_id, created_by, paid_by, status, shared, min_amount/max_amount, recurring,
+// installment, delete_requested, sort, cursor, limit ve include (splits, delete_requester)
+func expenseFilter(c echo.Context) (services.ExpenseFilter, bool) {
+	filter := services.ExpenseFilter{
+		Query:  c.QueryParam("q"),
+		Status: models.ExpenseStatus(c.QueryParam("status")),
+		Sort:   c.QueryParam("sort"),
+		Cursor: c.QueryParam("cursor"),
+	}
+	for param, dest := range map[string]*int{
+		"month": &filter.Month,
+		"year":  &filter.Year,
+		"limit": &filter.Limit,
+	} {
+		if v := c.QueryParam(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, false
+			}
+			*dest = n
+		}
+	}
+	for param, dest := range map[string]*uint{
+		"category_id": &filter.CategoryID,
+		"created_by":  &filter.CreatedBy,
+		"paid_by":     &filter.PaidBy,
+	} {
+		if v := c.QueryParam(param); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return filter, false
+			}
+			*dest = uint(id)
+		}
+	}
+	for param, dest := range map[string]**bool{
+		"shared":           &filter.Shared,
+		"recurring":        &filter.Recurring,
+		"installment":      &filter.Installment,
+		"delete_requested": &filter.DeleteRequested,
+	} {
+		if v := c.QueryParam(param); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return filter, false
+			}
+			*dest = &b
+		}
+	}
+	for param, dest := range map[string]**models.Money{
+		"min_amount": &filter.MinAmount,
+		"max_amount": &filter.MaxAmount,
+	} {
+		if v := c.QueryParam(param); v != "" {
+			m, err := models.ParseMoney(v)
+			if err != nil {
+				return filter, false
+			}
+			*dest = &m
+		}
 	}
-	if year == 0 {
-		year = now.Year()
+	for param, dest := range map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	} {
+		if v := c.QueryParam(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return filter, false
+			}
+			*dest = &t
+		}
+	}
+	if v := c.QueryParam("all"); v != "" {
+		all, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, false
+		}
+		filter.AllPeriods = all
 	}
+	if v := c.QueryParam("include"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			switch strings.TrimSpace(name) {
+			case "splits":
+				filter.WithSplits = true
+			case "delete_requester":
+				filter.WithRequester = true
+			default:
+				return filter, false
+			}
+		}
+	}
+	return filter, true
+}
 
-	expenses, err := h.service.List(month, year)
+// Giderleri süzerek listele. Dönem verilmezse hanenin içinde bulunduğu dönem kullanılır; tarih
+// aralığı verilirse dönem yerine o kullanılır. Yanıt gider dizisidir; filtreye uyan toplam adet
+// ve tutar ile sonraki sayfanın imleci başlıklarda döner. limit ya da cursor verilmezse
+// sayfalama yapılmaz.
+func (h *ExpenseHandler) List(c echo.Context) error {
+	filter, ok := expenseFilter(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz filtre"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	page, err := h.service.List(householdID, filter)
 	if err != nil {
+		var verr *services.ValidationError
+		if errors.As(err, &verr) {
+			return badRequest(c, err)
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Giderler yüklenemedi"})
 	}
-	return c.JSON(http.StatusOK, expenses)
+	header := c.Response().Header()
+	header.Set(HeaderTotalCount, strconv.FormatInt(page.TotalCount, 10))
+	header.Set(HeaderTotalAmount, page.TotalAmount.String())
+	if page.NextCursor != "" {
+		header.Set(HeaderNextCursor, page.NextCursor)
+	}
+	return c.JSON(http.StatusOK, page.Items)
 }
 
 func (h *ExpenseHandler) Create(c echo.Context) error {
@@ -53,38 +171,37 @@ func (h *ExpenseHandler) Create(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
-
-	if req.Month == 0 || req.Year == 0 {
-		now := time.Now()
-		if req.Month == 0 {
-			req.Month = int(now.Month())
-		}
-		if req.Year == 0 {
-			req.Year = now.Year()
+	householdID := c.Get("household_id").(uint)
+
+	// Dönem tarihten çıkar; month/year ile "geçen aya ait" olarak işaretlenebilir. Tarih
+	// verilmezse bugün (başka bir dönem istendiyse o dönemin ilk günü) kullanılır.
+	var date time.Time
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz tarih"})
 		}
-	}
-
-	if req.SplitRatio == 0 {
-		req.SplitRatio = 50
+		date = parsed
 	}
 
 	expense := &models.Expense{
+		HouseholdID:  householdID,
 		CreatedBy:    userID,
+		PaidBy:       req.PaidBy,
 		CategoryID:   req.CategoryID,
 		Description:  req.Description,
 		Amount:       req.Amount,
-		ExpenseDate:  time.Date(req.Year, time.Month(req.Month), 1, 0, 0, 0, 0, time.Local),
+		ExpenseDate:  date,
 		ExpenseMonth: req.Month,
 		ExpenseYear:  req.Year,
 		IsShared:     req.IsShared,
-		SplitRatio:   req.SplitRatio,
 	}
 
-	if err := h.service.Create(expense); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gider oluşturulamadı"})
+	if err := h.service.Create(expense, req.SplitSpec); err != nil {
+		return badRequest(c, err)
 	}
 
-	created, _ := h.service.GetByID(expense.ID)
+	created, _ := h.service.GetByID(householdID, expense.ID)
 	return c.JSON(http.StatusCreated, created)
 }
 
@@ -94,17 +211,18 @@ func (h *ExpenseHandler) Update(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
 	}
 
-	var updates map[string]interface{}
-	if err := c.Bind(&updates); err != nil {
+	var changes services.ExpenseChanges
+	if err := c.Bind(&changes); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
 	}
 
 	userID := c.Get("user_id").(uint)
-	if err := h.service.Update(uint(id), userID, updates); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.Update(householdID, uint(id), userID, changes); err != nil {
+		return badRequest(c, err)
 	}
 
-	updated, _ := h.service.GetByID(uint(id))
+	updated, _ := h.service.GetByID(householdID, uint(id))
 	return c.JSON(http.StatusOK, updated)
 }
 
@@ -115,15 +233,16 @@ func (h *ExpenseHandler) Delete(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
 	isAdmin, _ := c.Get("is_admin").(bool)
-	if err := h.service.Delete(uint(id), userID, isAdmin); err != nil {
+	if err := h.service.Delete(householdID, uint(id), userID, isAdmin); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
 	if isAdmin {
 		return c.JSON(http.StatusOK, map[string]string{"message": "Gider silindi"})
 	}
-	updated, _ := h.service.GetByID(uint(id))
+	updated, _ := h.service.GetByID(householdID, uint(id))
 	return c.JSON(http.StatusOK, updated)
 }
 
@@ -134,7 +253,8 @@ func (h *ExpenseHandler) ConfirmDelete(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
-	if err := h.service.ConfirmDelete(uint(id), userID); err != nil {
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.ConfirmDelete(householdID, uint(id), userID); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
@@ -148,11 +268,12 @@ func (h *ExpenseHandler) CancelDelete(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
-	if err := h.service.CancelDelete(uint(id), userID); err != nil {
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.CancelDelete(householdID, uint(id), userID); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	updated, _ := h.service.GetByID(uint(id))
+	updated, _ := h.service.GetByID(householdID, uint(id))
 	return c.JSON(http.StatusOK, updated)
 }
 
@@ -163,12 +284,13 @@ func (h *ExpenseHandler) Approve(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
 	isAdmin, _ := c.Get("is_admin").(bool)
-	if err := h.service.Approve(uint(id), userID, isAdmin); err != nil {
+	if err := h.service.Approve(householdID, uint(id), userID, isAdmin); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	updated, _ := h.service.GetByID(uint(id))
+	updated, _ := h.service.GetByID(householdID, uint(id))
 	return c.JSON(http.StatusOK, updated)
 }
 
@@ -179,11 +301,112 @@ func (h *ExpenseHandler) Reject(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	isAdmin, _ := c.Get("is_admin").(bool)
+	if err := h.service.Reject(householdID, uint(id), userID, isAdmin); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	updated, _ := h.service.GetByID(householdID, uint(id))
+	return c.JSON(http.StatusOK, updated)
+}
+
+// Onaylanmış gider için değişiklik önerisi
+func (h *ExpenseHandler) ProposeChange(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	var changes services.ExpenseChanges
+	if err := c.Bind(&changes); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	revision, err := h.service.ProposeChange(householdID, uint(id), userID, changes)
+	if err != nil {
+		return badRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, revision)
+}
+
+// revisionParams URL'deki :id/:rev değerlerini okur
+func revisionParams(c echo.Context) (expenseID, revisionID uint, ok bool) {
+	id, errID := strconv.ParseUint(c.Param("id"), 10, 32)
+	rev, errRev := strconv.ParseUint(c.Param("rev"), 10, 32)
+	return uint(id), uint(rev), errID == nil && errRev == nil
+}
+
+func (h *ExpenseHandler) ApproveChange(c echo.Context) error {
+	id, rev, ok := revisionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
 	isAdmin, _ := c.Get("is_admin").(bool)
-	if err := h.service.Reject(uint(id), userID, isAdmin); err != nil {
+	if err := h.service.ApproveChange(householdID, id, rev, userID, isAdmin); err != nil {
+		return badRequest(c, err)
+	}
+
+	updated, _ := h.service.GetByID(householdID, id)
+	return c.JSON(http.StatusOK, updated)
+}
+
+func (h *ExpenseHandler) RejectChange(c echo.Context) error {
+	id, rev, ok := revisionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.RejectChange(householdID, id, rev, userID); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Öneri reddedildi"})
+}
+
+// Giderin tüm sürümleri
+func (h *ExpenseHandler) History(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	revisions, err := h.service.History(householdID, uint(id))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Gider bulunamadı"})
+	}
+	return c.JSON(http.StatusOK, revisions)
+}
+
+type SetActualAmountRequest struct {
+	Amount models.Money `json:"amount"`
+}
+
+// Değişken tutarlı faturanın gerçek tutarı (sadece ödeyen)
+func (h *ExpenseHandler) SetActualAmount(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	var req SetActualAmountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.SetActualAmount(householdID, uint(id), userID, req.Amount); err != nil {
+		return badRequest(c, err)
+	}
 
-	updated, _ := h.service.GetByID(uint(id))
+	updated, _ := h.service.GetByID(householdID, uint(id))
 	return c.JSON(http.StatusOK, updated)
 }
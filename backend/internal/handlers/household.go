@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/caner/home-gider/internal/models"
+	"github.com/caner/home-gider/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+type HouseholdHandler struct {
+	service *services.HouseholdService
+}
+
+func NewHouseholdHandler(service *services.HouseholdService) *HouseholdHandler {
+	return &HouseholdHandler{service: service}
+}
+
+// Kullanıcının üye olduğu haneler
+func (h *HouseholdHandler) ListMine(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+	memberships, err := h.service.ListForUser(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Haneler yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, memberships)
+}
+
+// Aktif hanenin üyeleri
+func (h *HouseholdHandler) Members(c echo.Context) error {
+	householdID := c.Get("household_id").(uint)
+	members, err := h.service.Members(householdID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Üyeler yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+// Admin: Tüm haneleri listele
+func (h *HouseholdHandler) ListAll(c echo.Context) error {
+	households, err := h.service.ListAll()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Haneler yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, households)
+}
+
+type CreateHouseholdRequest struct {
+	Name string `json:"name"`
+}
+
+// Admin: Yeni hane oluştur
+func (h *HouseholdHandler) Create(c echo.Context) error {
+	var req CreateHouseholdRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Hane adı gerekli"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	household, err := h.service.Create(req.Name, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Hane oluşturulamadı"})
+	}
+	return c.JSON(http.StatusCreated, household)
+}
+
+type AddMemberRequest struct {
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// Admin: Haneye üye ekle
+func (h *HouseholdHandler) AddMember(c echo.Context) error {
+	householdID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	var req AddMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+	if req.Role == "" {
+		req.Role = string(models.RoleMember)
+	}
+
+	if err := h.service.AddMember(uint(householdID), req.UserID, models.HouseholdRole(req.Role)); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Üye eklendi"})
+}
+
+// Aktif hanenin dönem ayarları
+func (h *HouseholdHandler) Settings(c echo.Context) error {
+	householdID := c.Get("household_id").(uint)
+	household, err := h.service.Settings(householdID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Hane ayarları yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, household)
+}
+
+type HouseholdSettingsRequest struct {
+	PeriodStartDay int    `json:"period_start_day"`
+	Timezone       string `json:"timezone"`
+}
+
+// Hane yöneticisi: Dönem başlangıç günü ve saat dilimini güncelle
+func (h *HouseholdHandler) UpdateSettings(c echo.Context) error {
+	var req HouseholdSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	userID := c.Get("user_id").(uint)
+	household, err := h.service.UpdateSettings(householdID, userID, req.PeriodStartDay, req.Timezone)
+	if err != nil {
+		return badRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, household)
+}
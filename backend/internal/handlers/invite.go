@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/caner/home-gider/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+type InviteHandler struct {
+	service *services.InviteService
+}
+
+func NewInviteHandler(service *services.InviteService) *InviteHandler {
+	return &InviteHandler{service: service}
+}
+
+type CreateInviteRequest struct {
+	ExpiresInHours int `json:"expires_in_hours"`
+}
+
+// Aktif hane için davet kodu oluştur — kod yanıtta sadece bir kez döner
+func (h *InviteHandler) Create(c echo.Context) error {
+	var req CreateInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	invite, token, err := h.service.Create(householdID, userID, time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"invite": invite,
+		"token":  token,
+	})
+}
+
+// Hane yöneticisi: Bekleyen davetleri listele
+func (h *InviteHandler) List(c echo.Context) error {
+	householdID := c.Get("household_id").(uint)
+	invites, err := h.service.ListOutstanding(householdID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Davetler yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, invites)
+}
+
+// Hane yöneticisi: Daveti iptal et
+func (h *InviteHandler) Revoke(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.Revoke(householdID, uint(id)); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Davet iptal edildi"})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/caner/home-gider/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+type JobHandler struct {
+	jobs      *services.JobService
+	recurring *services.RecurringService
+}
+
+func NewJobHandler(jobs *services.JobService, recurring *services.RecurringService) *JobHandler {
+	return &JobHandler{jobs: jobs, recurring: recurring}
+}
+
+// Admin: işlerin son çalışmaları (?job=recurring&limit=50)
+func (h *JobHandler) List(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	runs, err := h.jobs.List(c.QueryParam("job"), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "İş kayıtları yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, runs)
+}
+
+// Admin: taksit/sabit gider işini hemen çalıştırır. ?dry_run=true ile hiçbir şey
+// yazmadan oluşturulacak giderleri döner.
+func (h *JobHandler) RunRecurring(c echo.Context) error {
+	if dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run")); dryRun {
+		plan, err := h.recurring.PlanRecurring()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"dry_run": true, "occurrences": plan})
+	}
+
+	userID := c.Get("user_id").(uint)
+	run, err := h.jobs.Run(services.JobRecurring, services.TriggerManual, userID, h.recurring.ProcessRecurring)
+	if errors.Is(err, services.ErrJobRunning) {
+		return c.JSON(http.StatusConflict, run)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, run)
+	}
+	return c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/caner/home-gider/internal/models"
+	"github.com/caner/home-gider/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+type PeriodHandler struct {
+	service *services.PeriodService
+}
+
+func NewPeriodHandler(service *services.PeriodService) *PeriodHandler {
+	return &PeriodHandler{service: service}
+}
+
+// periodParams URL'deki :year/:month değerlerini okur
+func periodParams(c echo.Context) (month, year int, ok bool) {
+	year, errYear := strconv.Atoi(c.Param("year"))
+	month, errMonth := strconv.Atoi(c.Param("month"))
+	return month, year, errYear == nil && errMonth == nil
+}
+
+func (h *PeriodHandler) List(c echo.Context) error {
+	householdID := c.Get("household_id").(uint)
+	locks, err := h.service.List(householdID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Dönemler yüklenemedi"})
+	}
+	return c.JSON(http.StatusOK, locks)
+}
+
+// Ayı kapatma talebi — ikinci üye aynı isteği yaptığında ay kapanır
+func (h *PeriodHandler) Close(c echo.Context) error {
+	month, year, ok := periodParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz dönem"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.Close(householdID, month, year, userID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	lock, _ := h.service.Get(householdID, month, year)
+	return c.JSON(http.StatusOK, lock)
+}
+
+// Kapalı ayı yeniden açma talebi — ikinci üye onaylayınca ay açılır
+func (h *PeriodHandler) Reopen(c echo.Context) error {
+	month, year, ok := periodParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz dönem"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.Reopen(householdID, month, year, userID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	lock, _ := h.service.Get(householdID, month, year)
+	return c.JSON(http.StatusOK, lock)
+}
+
+// Bekleyen kapatma/açma talebini iptal et
+func (h *PeriodHandler) CancelRequest(c echo.Context) error {
+	month, year, ok := periodParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz dönem"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	isAdmin, _ := c.Get("is_admin").(bool)
+	role, _ := c.Get("household_role").(string)
+	canManage := isAdmin || role == string(models.RoleAdmin)
+	if err := h.service.CancelRequest(householdID, month, year, userID, canManage); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	lock, _ := h.service.Get(householdID, month, year)
+	return c.JSON(http.StatusOK, lock)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/caner/home-gider/internal/models"
 	"github.com/caner/home-gider/internal/services"
@@ -18,18 +19,28 @@ func NewRecurringHandler(service *services.RecurringService) *RecurringHandler {
 }
 
 type CreateRecurringRequest struct {
-	CategoryID       uint    `json:"category_id"`
-	Description      string  `json:"description"`
-	Amount           float64 `json:"amount"`
-	TotalAmount      float64 `json:"total_amount"`
-	Type             string  `json:"type"`
-	InstallmentCount int     `json:"installment_count"`
-	IsShared         bool    `json:"is_shared"`
-	SplitRatio       float64 `json:"split_ratio"`
+	CategoryID       uint         `json:"category_id"`
+	Description      string       `json:"description"`
+	Amount           models.Money `json:"amount"`
+	PaidBy           uint         `json:"paid_by"`
+	TotalAmount      models.Money `json:"total_amount"`
+	Type             string       `json:"type"`
+	InstallmentCount int          `json:"installment_count"`
+	Principal        models.Money `json:"principal"`
+	InterestRate     float64      `json:"interest_rate"`
+	IsShared         bool         `json:"is_shared"`
+	SplitRatio       *float64     `json:"split_ratio"`
+	Frequency        string       `json:"frequency"`
+	Interval         int          `json:"interval"`
+	DayOfMonth       int          `json:"day_of_month"`
+	StartDate        string       `json:"start_date"`
+	EndDate          string       `json:"end_date"`
+	EstimateWindow   int          `json:"estimate_window"`
 }
 
 func (h *RecurringHandler) List(c echo.Context) error {
-	items, err := h.service.List()
+	householdID := c.Get("household_id").(uint)
+	items, err := h.service.List(householdID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Şablonlar yüklenemedi"})
 	}
@@ -43,19 +54,47 @@ func (h *RecurringHandler) Create(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
 
-	if req.SplitRatio == 0 {
-		req.SplitRatio = 50
+	// Oran verilmezse yarı yarıya; açıkça verilen 0 da geçerlidir
+	splitRatio := 50.0
+	if req.SplitRatio != nil {
+		splitRatio = *req.SplitRatio
 	}
 
 	item := &models.RecurringExpense{
-		CreatedBy:   userID,
-		CategoryID:  req.CategoryID,
-		Description: req.Description,
-		Amount:      req.Amount,
-		Type:        models.RecurringType(req.Type),
-		IsShared:    req.IsShared,
-		SplitRatio:  req.SplitRatio,
+		HouseholdID:    householdID,
+		CreatedBy:      userID,
+		PaidBy:         req.PaidBy,
+		CategoryID:     req.CategoryID,
+		Description:    req.Description,
+		Amount:         req.Amount,
+		Type:           models.RecurringType(req.Type),
+		InterestRate:   req.InterestRate,
+		IsShared:       req.IsShared,
+		SplitRatio:     splitRatio,
+		Frequency:      models.RecurringFrequency(req.Frequency),
+		Interval:       req.Interval,
+		EstimateWindow: req.EstimateWindow,
+	}
+
+	// Tarihler YYYY-MM-DD; başlangıç verilmezse bugün
+	if req.StartDate != "" {
+		start, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz başlangıç tarihi"})
+		}
+		item.StartDate = start
+	}
+	if req.EndDate != "" {
+		end, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz bitiş tarihi"})
+		}
+		item.EndDate = &end
+	}
+	if req.DayOfMonth > 0 {
+		item.DayOfMonth = &req.DayOfMonth
 	}
 
 	if req.TotalAmount > 0 {
@@ -64,9 +103,12 @@ func (h *RecurringHandler) Create(c echo.Context) error {
 	if req.InstallmentCount > 0 {
 		item.InstallmentCount = &req.InstallmentCount
 	}
+	if req.Principal > 0 {
+		item.Principal = &req.Principal
+	}
 
 	if err := h.service.Create(item); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return badRequest(c, err)
 	}
 
 	return c.JSON(http.StatusCreated, item)
@@ -78,16 +120,22 @@ func (h *RecurringHandler) Update(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
 	}
 
-	var updates map[string]interface{}
-	if err := c.Bind(&updates); err != nil {
+	var changes services.RecurringChanges
+	if err := c.Bind(&changes); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
 	}
 
 	userID := c.Get("user_id").(uint)
-	if err := h.service.Update(uint(id), userID, updates); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	householdID := c.Get("household_id").(uint)
+	revision, err := h.service.Update(householdID, uint(id), userID, changes)
+	if err != nil {
+		return badRequest(c, err)
 	}
 
+	// Onaylı şablonda değişiklik diğer üyenin onayını bekler
+	if revision != nil {
+		return c.JSON(http.StatusAccepted, revision)
+	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "Güncellendi"})
 }
 
@@ -98,7 +146,8 @@ func (h *RecurringHandler) Delete(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
-	if err := h.service.Delete(uint(id), userID); err != nil {
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.Delete(householdID, uint(id), userID); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
@@ -112,8 +161,9 @@ func (h *RecurringHandler) Approve(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
 	isAdmin, _ := c.Get("is_admin").(bool)
-	if err := h.service.Approve(uint(id), userID, isAdmin); err != nil {
+	if err := h.service.Approve(householdID, uint(id), userID, isAdmin); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
@@ -127,10 +177,277 @@ func (h *RecurringHandler) Reject(c echo.Context) error {
 	}
 
 	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
 	isAdmin, _ := c.Get("is_admin").(bool)
-	if err := h.service.Reject(uint(id), userID, isAdmin); err != nil {
+	if err := h.service.Reject(householdID, uint(id), userID, isAdmin); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Reddedildi"})
 }
+
+// Şablonun tüm sürümleri
+func (h *RecurringHandler) Revisions(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	revisions, err := h.service.Revisions(householdID, uint(id))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Şablon bulunamadı"})
+	}
+	return c.JSON(http.StatusOK, revisions)
+}
+
+func (h *RecurringHandler) ApproveRevision(c echo.Context) error {
+	id, rev, ok := revisionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	isAdmin, _ := c.Get("is_admin").(bool)
+	if err := h.service.ApproveRevision(householdID, id, rev, userID, isAdmin); err != nil {
+		return badRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Değişiklik onaylandı"})
+}
+
+func (h *RecurringHandler) RejectRevision(c echo.Context) error {
+	id, rev, ok := revisionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.RejectRevision(householdID, id, rev, userID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Değişiklik reddedildi"})
+}
+
+// Taksitli şablonun ödeme planı
+func (h *RecurringHandler) Schedule(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	schedule, err := h.service.Schedule(householdID, uint(id))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// Kalan taksitleri tek giderle erken kapatır
+func (h *RecurringHandler) Payoff(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	expense, err := h.service.Payoff(householdID, uint(id), userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, expense)
+}
+
+type RestructureRequest struct {
+	InstallmentsRemaining int      `json:"installments_remaining"`
+	InterestRate          *float64 `json:"interest_rate"`
+}
+
+// Kalan borcu yeni taksit sayısına göre yeniden planlama önerir; diğer üye onaylayınca uygulanır
+func (h *RecurringHandler) Restructure(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	var req RestructureRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	revision, err := h.service.Restructure(householdID, uint(id), userID, req.InstallmentsRemaining, req.InterestRate)
+	if err != nil {
+		return badRequest(c, err)
+	}
+	return c.JSON(http.StatusAccepted, revision)
+}
+
+type ReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Şablonu bugünden itibaren duraklatır
+func (h *RecurringHandler) Pause(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	var req ReasonRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.Pause(householdID, uint(id), userID, req.Reason); err != nil {
+		return badRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Duraklatıldı"})
+}
+
+// Duraklatılmış şablonu devam ettirir
+func (h *RecurringHandler) Resume(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	var req ReasonRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.Resume(householdID, uint(id), userID, req.Reason); err != nil {
+		return badRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Devam ettirildi"})
+}
+
+// Şablonun duraklatma geçmişi
+func (h *RecurringHandler) Pauses(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	pauses, err := h.service.Pauses(householdID, uint(id))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Şablon bulunamadı"})
+	}
+	return c.JSON(http.StatusOK, pauses)
+}
+
+// Şablonun atlanan dönemleri
+func (h *RecurringHandler) Skips(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	householdID := c.Get("household_id").(uint)
+	skips, err := h.service.Skips(householdID, uint(id))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Şablon bulunamadı"})
+	}
+	return c.JSON(http.StatusOK, skips)
+}
+
+type SkipRequest struct {
+	Month  int    `json:"month"`
+	Year   int    `json:"year"`
+	Reason string `json:"reason"`
+}
+
+// Şablonun bir dönemini atlar
+func (h *RecurringHandler) AddSkip(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	var req SkipRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	skip, err := h.service.AddSkip(householdID, uint(id), userID, req.Month, req.Year, req.Reason)
+	if err != nil {
+		return badRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, skip)
+}
+
+// Atlanan dönemi geri alır
+func (h *RecurringHandler) DeleteSkip(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+	skipID, err := strconv.ParseUint(c.Param("skip"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.DeleteSkip(householdID, uint(id), uint(skipID), userID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Silindi"})
+}
+
+// Şablonun sıradaki tekrarları (?count=N, varsayılan 6)
+func (h *RecurringHandler) Upcoming(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+	count, _ := strconv.Atoi(c.QueryParam("count"))
+
+	householdID := c.Get("household_id").(uint)
+	upcoming, err := h.service.Upcoming(householdID, uint(id), count)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Şablon bulunamadı"})
+	}
+	return c.JSON(http.StatusOK, upcoming)
+}
+
+// Şablonlardan önümüzdeki ayların tahmini giderleri (?months=N, varsayılan 3)
+func (h *RecurringHandler) Forecast(c echo.Context) error {
+	months, _ := strconv.Atoi(c.QueryParam("months"))
+
+	householdID := c.Get("household_id").(uint)
+	forecast, err := h.service.Forecast(householdID, months)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Tahmin hesaplanamadı"})
+	}
+	return c.JSON(http.StatusOK, forecast)
+}
+
+// Admin: verilen tarihten bugüne kaçırılmış tekrarları oluşturur (?from=YYYY-MM-DD)
+func (h *RecurringHandler) Backfill(c echo.Context) error {
+	from, err := time.Parse("2006-01-02", c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz başlangıç tarihi (YYYY-MM-DD)"})
+	}
+	if from.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Başlangıç tarihi gelecekte olamaz"})
+	}
+
+	created, err := h.service.Backfill(from)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error(), "created": created})
+	}
+	return c.JSON(http.StatusOK, map[string]int{"created": created})
+}
@@ -3,8 +3,8 @@ package handlers
 import (
 	"net/http"
 	"strconv"
-	"time"
 
+	"github.com/caner/home-gider/internal/models"
 	"github.com/caner/home-gider/internal/services"
 	"github.com/labstack/echo/v4"
 )
@@ -18,17 +18,12 @@ func NewSettlementHandler(service *services.SettlementService) *SettlementHandle
 }
 
 func (h *SettlementHandler) ListPayments(c echo.Context) error {
-	now := time.Now()
+	householdID := c.Get("household_id").(uint)
+	// Dönem verilmezse hanenin içinde bulunduğu dönem kullanılır
 	month, _ := strconv.Atoi(c.QueryParam("month"))
 	year, _ := strconv.Atoi(c.QueryParam("year"))
-	if month == 0 {
-		month = int(now.Month())
-	}
-	if year == 0 {
-		year = now.Year()
-	}
 
-	payments, err := h.service.GetPayments(month, year)
+	payments, err := h.service.GetPayments(householdID, month, year)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ödemeler yüklenemedi"})
 	}
@@ -36,11 +31,11 @@ func (h *SettlementHandler) ListPayments(c echo.Context) error {
 }
 
 type AddPaymentRequest struct {
-	Month   int     `json:"month"`
-	Year    int     `json:"year"`
-	PayerID uint    `json:"payer_id"`
-	PayeeID uint    `json:"payee_id"`
-	Amount  float64 `json:"amount"`
+	Month   int          `json:"month"`
+	Year    int          `json:"year"`
+	PayerID uint         `json:"payer_id"`
+	PayeeID uint         `json:"payee_id"`
+	Amount  models.Money `json:"amount"`
 }
 
 func (h *SettlementHandler) AddPayment(c echo.Context) error {
@@ -54,23 +49,132 @@ func (h *SettlementHandler) AddPayment(c echo.Context) error {
 
 	// Sadece borçlu olan kişi ödeme ekleyebilir
 	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
 	if req.PayerID != userID {
 		return c.JSON(http.StatusForbidden, map[string]string{"error": "Sadece borçlu kişi ödeme ekleyebilir"})
 	}
 
-	if err := h.service.AddPayment(req.Month, req.Year, req.PayerID, req.PayeeID, req.Amount); err != nil {
+	if err := h.service.AddPayment(householdID, req.Month, req.Year, req.PayerID, req.PayeeID, req.Amount); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	return c.JSON(http.StatusCreated, map[string]string{"message": "Ödeme kaydedildi"})
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Ödeme kaydedildi, alacaklının onayı bekleniyor"})
 }
 
+// DeletePayment onaylanmış ödemede silme talebi açar; diğer durumlarda ödemeyi siler
 func (h *SettlementHandler) DeletePayment(c echo.Context) error {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
 	}
-	if err := h.service.DeletePayment(uint(id)); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ödeme silinemedi"})
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	deleted, err := h.service.DeletePayment(householdID, uint(id), userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if deleted {
+		return c.JSON(http.StatusOK, map[string]string{"message": "Ödeme silindi"})
+	}
+	updated, _ := h.service.GetPayment(householdID, uint(id))
+	return c.JSON(http.StatusOK, updated)
+}
+
+// Alacaklı parayı aldığını onaylar
+func (h *SettlementHandler) ConfirmPayment(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.ConfirmPayment(householdID, uint(id), userID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	updated, _ := h.service.GetPayment(householdID, uint(id))
+	return c.JSON(http.StatusOK, updated)
+}
+
+// Alacaklı parayı almadığını bildirir
+func (h *SettlementHandler) DisputePayment(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.DisputePayment(householdID, uint(id), userID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	updated, _ := h.service.GetPayment(householdID, uint(id))
+	return c.JSON(http.StatusOK, updated)
+}
+
+func (h *SettlementHandler) ConfirmDeletePayment(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.ConfirmDeletePayment(householdID, uint(id), userID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
+
 	return c.JSON(http.StatusOK, map[string]string{"message": "Ödeme silindi"})
 }
+
+func (h *SettlementHandler) CancelDeletePayment(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz ID"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.CancelDeletePayment(householdID, uint(id), userID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	updated, _ := h.service.GetPayment(householdID, uint(id))
+	return c.JSON(http.StatusOK, updated)
+}
+
+// Tüm aylar boyunca biriken net bakiye
+func (h *SettlementHandler) GetBalance(c echo.Context) error {
+	householdID := c.Get("household_id").(uint)
+	balance, err := h.service.GetBalance(householdID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Bakiye hesaplanamadı"})
+	}
+	return c.JSON(http.StatusOK, balance)
+}
+
+type AddBalancePaymentRequest struct {
+	PayeeID uint         `json:"payee_id"`
+	Amount  models.Money `json:"amount"`
+}
+
+// Belirli bir aya değil, kümülatif bakiyeye karşı ödeme
+func (h *SettlementHandler) AddBalancePayment(c echo.Context) error {
+	var req AddBalancePaymentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Geçersiz istek"})
+	}
+	if req.Amount <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Tutar sıfırdan büyük olmalı"})
+	}
+
+	userID := c.Get("user_id").(uint)
+	householdID := c.Get("household_id").(uint)
+	if err := h.service.AddBalancePayment(householdID, userID, req.PayeeID, req.Amount); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Ödeme kaydedildi, alacaklının onayı bekleniyor"})
+}
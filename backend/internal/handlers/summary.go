@@ -3,7 +3,6 @@ package handlers
 import (
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/caner/home-gider/internal/services"
 	"github.com/labstack/echo/v4"
@@ -18,19 +17,14 @@ func NewSummaryHandler(service *services.SettlementService) *SummaryHandler {
 }
 
 func (h *SummaryHandler) GetSummary(c echo.Context) error {
-	now := time.Now()
+	householdID := c.Get("household_id").(uint)
+	// Dönem verilmezse hanenin içinde bulunduğu dönem kullanılır
 	month, _ := strconv.Atoi(c.QueryParam("month"))
 	year, _ := strconv.Atoi(c.QueryParam("year"))
-	if month == 0 {
-		month = int(now.Month())
-	}
-	if year == 0 {
-		year = now.Year()
-	}
 
 	sharedOnly := c.QueryParam("shared_only") == "true"
 
-	summary, err := h.service.GetMonthlySummary(month, year, sharedOnly)
+	summary, err := h.service.GetMonthlySummary(householdID, month, year, sharedOnly)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Özet hesaplanamadı"})
 	}
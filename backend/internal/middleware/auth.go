@@ -9,10 +9,12 @@ import (
 )
 
 type JWTClaims struct {
-	UserID      uint   `json:"user_id"`
-	Username    string `json:"username"`
-	DisplayName string `json:"display_name"`
-	IsAdmin     bool   `json:"is_admin"`
+	UserID        uint   `json:"user_id"`
+	Username      string `json:"username"`
+	DisplayName   string `json:"display_name"`
+	IsAdmin       bool   `json:"is_admin"`
+	HouseholdID   uint   `json:"household_id"`
+	HouseholdRole string `json:"household_role"`
 	jwt.RegisteredClaims
 }
 
@@ -48,6 +50,8 @@ func AuthMiddleware(secret string) echo.MiddlewareFunc {
 			c.Set("username", claims.Username)
 			c.Set("display_name", claims.DisplayName)
 			c.Set("is_admin", claims.IsAdmin)
+			c.Set("household_id", claims.HouseholdID)
+			c.Set("household_role", claims.HouseholdRole)
 			return next(c)
 		}
 	}
@@ -64,3 +68,17 @@ func AdminMiddleware() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// HouseholdAdminMiddleware aktif hanede yönetici rolü olanlara (veya sistem admin'ine) izin verir
+func HouseholdAdminMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			isAdmin, _ := c.Get("is_admin").(bool)
+			role, _ := c.Get("household_role").(string)
+			if !isAdmin && role != "admin" {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "Hane yöneticisi yetkisi gerekiyor"})
+			}
+			return next(c)
+		}
+	}
+}
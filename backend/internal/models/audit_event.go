@@ -0,0 +1,74 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate         AuditAction = "create"
+	AuditUpdate         AuditAction = "update"
+	AuditDelete         AuditAction = "delete"
+	AuditApprove        AuditAction = "approve"
+	AuditReject         AuditAction = "reject"
+	AuditDeleteRequest  AuditAction = "delete_request"
+	AuditDeleteCancel   AuditAction = "delete_cancel"
+	AuditPasswordChange AuditAction = "password_change"
+	AuditPasswordReset  AuditAction = "password_reset"
+	AuditRegister       AuditAction = "register"
+	AuditGenerate       AuditAction = "generate"
+	AuditPause          AuditAction = "pause"
+	AuditResume         AuditAction = "resume"
+)
+
+// AuditChange bir alanın işlemden önceki ve sonraki değeri
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges alan adı → değişiklik. Veritabanında jsonb olarak saklanır.
+type AuditChanges map[string]AuditChange
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (c *AuditChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("audit değişiklikleri için desteklenmeyen tip: %T", value)
+	}
+}
+
+// AuditEvent değişmez işlem kaydı. Sadece eklenir, hiçbir zaman güncellenmez veya silinmez.
+// Hane dışı işlemlerde (şifre sıfırlama) HouseholdID, zamanlanmış işlerde ActorID boştur.
+type AuditEvent struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	HouseholdID *uint        `json:"household_id" gorm:"index"`
+	ActorID     *uint        `json:"actor_id"`
+	Actor       *User        `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	EntityType  string       `json:"entity_type" gorm:"size:30;not null"`
+	EntityID    uint         `json:"entity_id" gorm:"not null"`
+	Action      AuditAction  `json:"action" gorm:"size:30;not null"`
+	Changes     AuditChanges `json:"changes" gorm:"type:jsonb;not null"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
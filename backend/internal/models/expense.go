@@ -11,27 +11,35 @@ const (
 )
 
 type Expense struct {
-	ID                 uint          `json:"id" gorm:"primaryKey"`
-	CreatedBy          uint          `json:"created_by" gorm:"not null"`
-	Creator            User          `json:"creator" gorm:"foreignKey:CreatedBy"`
-	CategoryID         uint          `json:"category_id" gorm:"not null"`
-	Category           Category      `json:"category" gorm:"foreignKey:CategoryID"`
-	Description        string        `json:"description" gorm:"size:255;not null"`
-	Amount             float64       `json:"amount" gorm:"type:decimal(10,2);not null"`
-	ExpenseDate        time.Time     `json:"expense_date" gorm:"type:date;not null"`
-	ExpenseMonth       int           `json:"expense_month" gorm:"not null"`
-	ExpenseYear        int           `json:"expense_year" gorm:"not null"`
-	IsShared           bool          `json:"is_shared" gorm:"default:true"`
-	SplitRatio         float64       `json:"split_ratio" gorm:"type:decimal(5,2);default:50"`
-	IsInstallment      bool          `json:"is_installment" gorm:"default:false"`
-	InstallmentNo      *int          `json:"installment_no"`
-	InstallmentTotal   *int          `json:"installment_total"`
-	RecurringExpenseID *uint         `json:"recurring_expense_id"`
-	Status             ExpenseStatus `json:"status" gorm:"size:20;default:'pending'"`
-	ApprovedBy         *uint         `json:"approved_by"`
-	Approver           *User         `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy"`
-	ApprovedAt         *time.Time    `json:"approved_at"`
-	DeleteRequestedBy  *uint         `json:"delete_requested_by"`
-	DeleteRequester    *User         `json:"delete_requester,omitempty" gorm:"foreignKey:DeleteRequestedBy"`
-	CreatedAt          time.Time     `json:"created_at"`
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	HouseholdID         uint           `json:"household_id" gorm:"not null;index"`
+	CreatedBy           uint           `json:"created_by" gorm:"not null"`
+	Creator             User           `json:"creator" gorm:"foreignKey:CreatedBy"`
+	PaidBy              uint           `json:"paid_by" gorm:"not null"`
+	Payer               User           `json:"payer" gorm:"foreignKey:PaidBy"`
+	CategoryID          uint           `json:"category_id" gorm:"not null"`
+	Category            Category       `json:"category" gorm:"foreignKey:CategoryID"`
+	Description         string         `json:"description" gorm:"size:255;not null"`
+	Amount              Money          `json:"amount" gorm:"type:bigint;not null"`
+	IsEstimate          bool           `json:"is_estimate" gorm:"default:false"`
+	ActualSetBy         *uint          `json:"actual_set_by"`
+	ExpenseDate         time.Time      `json:"expense_date" gorm:"type:date;not null"`
+	ExpenseMonth        int            `json:"expense_month" gorm:"not null"`
+	ExpenseYear         int            `json:"expense_year" gorm:"not null"`
+	OccurrenceDate      *time.Time     `json:"occurrence_date" gorm:"type:date"`
+	IsShared            bool           `json:"is_shared" gorm:"default:true"`
+	SplitMode           SplitMode      `json:"split_mode" gorm:"size:20;not null;default:'equal'"`
+	Splits              []ExpenseSplit `json:"splits" gorm:"foreignKey:ExpenseID;constraint:OnDelete:CASCADE"`
+	IsInstallment       bool           `json:"is_installment" gorm:"default:false"`
+	InstallmentNo       *int           `json:"installment_no"`
+	InstallmentTotal    *int           `json:"installment_total"`
+	RecurringExpenseID  *uint          `json:"recurring_expense_id"`
+	RecurringRevisionID *uint          `json:"recurring_revision_id"`
+	Status              ExpenseStatus  `json:"status" gorm:"size:20;default:'pending'"`
+	ApprovedBy          *uint          `json:"approved_by"`
+	Approver            *User          `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy"`
+	ApprovedAt          *time.Time     `json:"approved_at"`
+	DeleteRequestedBy   *uint          `json:"delete_requested_by"`
+	DeleteRequester     *User          `json:"delete_requester,omitempty" gorm:"foreignKey:DeleteRequestedBy"`
+	CreatedAt           time.Time      `json:"created_at"`
 }
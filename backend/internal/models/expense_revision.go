@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RevisionSplit bir sürümdeki katılımcı payı; ExpenseSplit'in kimliksiz kopyası
+type RevisionSplit struct {
+	UserID     uint     `json:"user_id"`
+	Shares     *int     `json:"shares,omitempty"`
+	Percentage *float64 `json:"percentage,omitempty"`
+	Amount     Money    `json:"amount"`
+}
+
+// RevisionSplits sürümün payları. Veritabanında jsonb olarak saklanır.
+type RevisionSplits []RevisionSplit
+
+func (r RevisionSplits) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (r *RevisionSplits) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("sürüm payları için desteklenmeyen tip: %T", value)
+	}
+}
+
+// ExpenseRevision giderin bir sürümü. Onaylanmış bir gider doğrudan düzenlenemez; oluşturan
+// yeni değerleri önerir (pending), diğer üye onaylarsa gider bu değerlere güncellenir.
+// İlk sürüm giderin ilk hâlidir; onaylanan ve reddedilen tüm öneriler saklanır.
+type ExpenseRevision struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ExpenseID    uint           `json:"expense_id" gorm:"not null;index"`
+	Version      int            `json:"version" gorm:"not null"`
+	ProposedBy   uint           `json:"proposed_by" gorm:"not null"`
+	Proposer     User           `json:"proposer" gorm:"foreignKey:ProposedBy"`
+	Status       ExpenseStatus  `json:"status" gorm:"size:20;not null"`
+	CategoryID   uint           `json:"category_id" gorm:"not null"`
+	Description  string         `json:"description" gorm:"size:255;not null"`
+	Amount       Money          `json:"amount" gorm:"type:bigint;not null"`
+	PaidBy       uint           `json:"paid_by" gorm:"not null"`
+	ExpenseDate  time.Time      `json:"expense_date" gorm:"type:date;not null"`
+	ExpenseMonth int            `json:"expense_month" gorm:"not null"`
+	ExpenseYear  int            `json:"expense_year" gorm:"not null"`
+	IsShared     bool           `json:"is_shared"`
+	SplitMode    SplitMode      `json:"split_mode" gorm:"size:20;not null"`
+	Splits       RevisionSplits `json:"splits" gorm:"type:jsonb;not null"`
+	ReviewedBy   *uint          `json:"reviewed_by"`
+	Reviewer     *User          `json:"reviewer,omitempty" gorm:"foreignKey:ReviewedBy"`
+	ReviewedAt   *time.Time     `json:"reviewed_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
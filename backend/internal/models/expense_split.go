@@ -0,0 +1,22 @@
+package models
+
+type SplitMode string
+
+const (
+	SplitEqual      SplitMode = "equal"
+	SplitShares     SplitMode = "shares"
+	SplitPercentage SplitMode = "percentage"
+	SplitExact      SplitMode = "exact"
+)
+
+// ExpenseSplit giderin bir katılımcıya düşen payı. Shares/Percentage bölüşümün
+// nasıl tanımlandığını saklar; hesaplaşmada her zaman Amount kullanılır.
+type ExpenseSplit struct {
+	ID         uint     `json:"id" gorm:"primaryKey"`
+	ExpenseID  uint     `json:"expense_id" gorm:"not null;index"`
+	UserID     uint     `json:"user_id" gorm:"not null"`
+	User       User     `json:"user" gorm:"foreignKey:UserID"`
+	Shares     *int     `json:"shares,omitempty"`
+	Percentage *float64 `json:"percentage,omitempty" gorm:"type:decimal(5,2)"`
+	Amount     Money    `json:"amount" gorm:"type:bigint;not null"`
+}
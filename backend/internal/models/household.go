@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type HouseholdRole string
+
+const (
+	RoleAdmin  HouseholdRole = "admin"
+	RoleMember HouseholdRole = "member"
+)
+
+// Household birlikte gider paylaşan ev — tüm gider, şablon ve ödemeler bir haneye aittir.
+// PeriodStartDay dönemin başladığı gündür (1-28); Timezone boşsa sunucunun saat dilimi kullanılır.
+type Household struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"size:100;not null"`
+	PeriodStartDay int       `json:"period_start_day" gorm:"not null;default:1"`
+	Timezone       string    `json:"timezone" gorm:"size:64;not null;default:''"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// HouseholdMember kullanıcının bir haneye üyeliği ve oradaki rolü
+type HouseholdMember struct {
+	HouseholdID uint          `json:"household_id" gorm:"primaryKey"`
+	Household   Household     `json:"household" gorm:"foreignKey:HouseholdID"`
+	UserID      uint          `json:"user_id" gorm:"primaryKey"`
+	User        User          `json:"user" gorm:"foreignKey:UserID"`
+	Role        HouseholdRole `json:"role" gorm:"size:20;not null;default:'member'"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Invite haneye katılmak için tek kullanımlık, süreli davet. Kodun kendisi saklanmaz,
+// sadece SHA-256 özeti tutulur; kod oluşturulduğunda bir kez gösterilir.
+type Invite struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	HouseholdID uint       `json:"household_id" gorm:"not null;index"`
+	CreatedBy   uint       `json:"created_by" gorm:"not null"`
+	Creator     User       `json:"creator" gorm:"foreignKey:CreatedBy"`
+	TokenHash   string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	UsedBy      *uint      `json:"used_by"`
+	User        *User      `json:"user,omitempty" gorm:"foreignKey:UsedBy"`
+	UsedAt      *time.Time `json:"used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobSkipped   JobStatus = "skipped"
+)
+
+// JobRun zamanlanmış ya da elle tetiklenmiş bir işin tek çalışması
+type JobRun struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Job          string     `json:"job" gorm:"size:50;not null;index"`
+	Trigger      string     `json:"trigger" gorm:"size:20;not null"`
+	TriggeredBy  *uint      `json:"triggered_by"`
+	Status       JobStatus  `json:"status" gorm:"size:20;not null"`
+	Error        string     `json:"error,omitempty" gorm:"type:text"`
+	ItemsCreated int        `json:"items_created"`
+	StartedAt    time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
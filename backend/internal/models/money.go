@@ -0,0 +1,180 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money kuruş cinsinden tam sayı tutar. Float yuvarlama kayıplarını önlemek için
+// veritabanında bigint olarak saklanır, JSON'da ise ondalıklı sayı (12.50) olarak görünür.
+type Money int64
+
+var errInvalidMoney = errors.New("geçersiz tutar")
+
+// ErrNoWeights tüm ağırlıklar sıfır olduğunda tutar dağıtılamaz
+var ErrNoWeights = errors.New("tutar dağıtılamaz: ağırlıkların toplamı sıfır")
+
+// maxLira kuruşa çevrildiğinde int64'e sığan en büyük lira değeri
+const maxLira = math.MaxInt64/100 - 1
+
+// ParseMoney "12", "12.5", "12,5" veya "-12.05" gibi bir metni kuruş hassasiyetinde çözümler.
+// Ondalık ayırıcı nokta ya da virgül olabilir; ikiden fazla ondalık hane kabul edilmez.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(strings.Replace(s, ",", ".", 1), ".")
+	if whole == "" || (hasFrac && (frac == "" || len(frac) > 2)) {
+		return 0, errInvalidMoney
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	// ParseInt işaret kabul ettiği için ("1.+5") yalnızca rakamlara izin verilir
+	if !digitsOnly(whole) || !digitsOnly(frac) {
+		return 0, errInvalidMoney
+	}
+	lira, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || lira > maxLira {
+		return 0, errInvalidMoney
+	}
+	kurus, _ := strconv.ParseInt(frac, 10, 64)
+
+	m := Money(lira*100 + kurus)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+func digitsOnly(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON hem sayı (12.5) hem de metin ("12.50") biçimini kabul eder
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan veritabanındaki kuruş değerini okur
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v)
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("money için desteklenmeyen tip: %T", value)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*m = Money(v)
+	return nil
+}
+
+// Value tutarı kuruş olarak yazar
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// Allocate tutarı ağırlıklara göre en büyük kalan (largest remainder) yöntemiyle böler.
+// Parçaların toplamı her zaman tutarın kendisine eşittir; artan kuruşlar kalanı en büyük
+// olan parçalara, eşitlikte önce gelene verilir. Pozitif ağırlık yoksa ErrNoWeights döner.
+func (m Money) Allocate(weights []int64) ([]Money, error) {
+	parts := make([]Money, len(weights))
+	var weightSum int64
+	for _, w := range weights {
+		if w > 0 {
+			weightSum += w
+		}
+	}
+	if weightSum == 0 {
+		return nil, ErrNoWeights
+	}
+
+	total := int64(m)
+	negative := total < 0
+	if negative {
+		total = -total
+	}
+
+	remainders := make([]int64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		parts[i] = Money(total * w / weightSum)
+		remainders[i] = total * w % weightSum
+		allocated += int64(parts[i])
+	}
+
+	for left := total - allocated; left > 0; left-- {
+		best := -1
+		for i, w := range weights {
+			if w <= 0 {
+				continue
+			}
+			if best == -1 || remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best] = -1
+	}
+
+	if negative {
+		for i := range parts {
+			parts[i] = -parts[i]
+		}
+	}
+	return parts, nil
+}
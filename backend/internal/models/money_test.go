@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{"12", 1200},
+		{"12.50", 1250},
+		{"12.5", 1250},
+		{"12,5", 1250},
+		{" 7.05 ", 705},
+		{"-0,01", -1},
+		{"-12.05", -1205},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) hata: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, beklenen %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMoneyInvalid(t *testing.T) {
+	for _, in := range []string{
+		"", "-", "abc", "12.", ".5", "12.505", "1.+5", "1.-5", "+12", "12,5,0", "1.2.3",
+		"1e3", "92233720368547758.07",
+	} {
+		if _, err := ParseMoney(in); err == nil {
+			t.Errorf("ParseMoney(%q) hata vermeliydi", in)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   Money
+		weights []int64
+		want    []Money
+	}{
+		{"eşit", 900, []int64{1, 1, 1}, []Money{300, 300, 300}},
+		{"kalan önce gelene", 1000, []int64{1, 1, 1}, []Money{334, 333, 333}},
+		{"kalan en büyük artığa", 100, []int64{1, 2}, []Money{33, 67}},
+		{"negatif tutar", -1000, []int64{1, 1, 1}, []Money{-334, -333, -333}},
+		{"sıfır tutar", 0, []int64{1, 3}, []Money{0, 0}},
+		{"sıfır ağırlık pay almaz", 1001, []int64{0, 1, 1}, []Money{0, 501, 500}},
+	}
+	for _, tt := range tests {
+		got, err := tt.total.Allocate(tt.weights)
+		if err != nil {
+			t.Errorf("%s: hata: %v", tt.name, err)
+			continue
+		}
+		var sum Money
+		for i := range got {
+			sum += got[i]
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: parça %d = %d, beklenen %d", tt.name, i, got[i], tt.want[i])
+			}
+		}
+		if sum != tt.total {
+			t.Errorf("%s: parçaların toplamı %d, beklenen %d", tt.name, sum, tt.total)
+		}
+	}
+}
+
+func TestAllocateNoWeights(t *testing.T) {
+	for _, weights := range [][]int64{nil, {0, 0}, {-1, 0}} {
+		if _, err := Money(100).Allocate(weights); !errors.Is(err, ErrNoWeights) {
+			t.Errorf("Allocate(%v) hata = %v, beklenen ErrNoWeights", weights, err)
+		}
+	}
+}
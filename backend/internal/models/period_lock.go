@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type PeriodStatus string
+
+const (
+	PeriodOpen            PeriodStatus = "open"
+	PeriodCloseRequested  PeriodStatus = "close_requested"
+	PeriodClosed          PeriodStatus = "closed"
+	PeriodReopenRequested PeriodStatus = "reopen_requested"
+)
+
+// PeriodLock bir ayın kapatılma durumu. Kapatma ve yeniden açma, silme onayında
+// olduğu gibi bir üyenin talebi ve diğer üyenin onayıyla gerçekleşir.
+type PeriodLock struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	HouseholdID uint         `json:"household_id" gorm:"not null;uniqueIndex:idx_period_locks_period"`
+	Month       int          `json:"month" gorm:"not null;uniqueIndex:idx_period_locks_period"`
+	Year        int          `json:"year" gorm:"not null;uniqueIndex:idx_period_locks_period"`
+	Status      PeriodStatus `json:"status" gorm:"size:20;not null"`
+	RequestedBy *uint        `json:"requested_by"`
+	Requester   *User        `json:"requester,omitempty" gorm:"foreignKey:RequestedBy"`
+	ClosedBy    *uint        `json:"closed_by"`
+	ClosedAt    *time.Time   `json:"closed_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// IsLocked dönem kapalı mı? Yeniden açma talebi onaylanana kadar dönem kapalı kalır.
+func (p *PeriodLock) IsLocked() bool {
+	return p.Status == PeriodClosed || p.Status == PeriodReopenRequested
+}
@@ -7,25 +7,54 @@ type RecurringType string
 const (
 	TypeInstallment RecurringType = "installment"
 	TypeRecurring   RecurringType = "recurring"
+	// TypeVariable tutarı her dönem değişen faturalar: tahmini tutarla onay bekleyen gider üretir
+	TypeVariable RecurringType = "variable"
 )
 
+// RecurringFrequency şablonun tekrar birimi; Interval ile birlikte "her N hafta/ay/yıl" olur
+type RecurringFrequency string
+
+const (
+	FrequencyWeekly  RecurringFrequency = "weekly"
+	FrequencyMonthly RecurringFrequency = "monthly"
+	FrequencyYearly  RecurringFrequency = "yearly"
+)
+
+// RecurringExpense tekrarlayan gider şablonu. Taksitlerde Principal güncel planın anaparasıdır;
+// plan yeniden yapılandırılınca kalan borç olur ve ScheduleOffset o ana kadar ödenmiş taksit sayısını tutar.
 type RecurringExpense struct {
-	ID                    uint          `json:"id" gorm:"primaryKey"`
-	CreatedBy             uint          `json:"created_by" gorm:"not null"`
-	Creator               User          `json:"creator" gorm:"foreignKey:CreatedBy"`
-	CategoryID            uint          `json:"category_id" gorm:"not null"`
-	Category              Category      `json:"category" gorm:"foreignKey:CategoryID"`
-	Description           string        `json:"description" gorm:"size:255;not null"`
-	Amount                float64       `json:"amount" gorm:"type:decimal(10,2);not null"`
-	TotalAmount           *float64      `json:"total_amount" gorm:"type:decimal(10,2)"`
-	Type                  RecurringType `json:"type" gorm:"size:20;not null"`
-	InstallmentCount      *int          `json:"installment_count"`
-	InstallmentsRemaining *int          `json:"installments_remaining"`
-	IsShared              bool          `json:"is_shared" gorm:"default:true"`
-	SplitRatio            float64       `json:"split_ratio" gorm:"type:decimal(5,2);default:50"`
-	IsActive              bool          `json:"is_active" gorm:"default:true"`
-	Status                ExpenseStatus `json:"status" gorm:"size:20;default:'pending'"`
-	ApprovedBy            *uint         `json:"approved_by"`
-	Approver              *User         `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy"`
-	CreatedAt             time.Time     `json:"created_at"`
+	ID                    uint               `json:"id" gorm:"primaryKey"`
+	HouseholdID           uint               `json:"household_id" gorm:"not null;index"`
+	CreatedBy             uint               `json:"created_by" gorm:"not null"`
+	Creator               User               `json:"creator" gorm:"foreignKey:CreatedBy"`
+	PaidBy                uint               `json:"paid_by" gorm:"not null"`
+	Payer                 User               `json:"payer" gorm:"foreignKey:PaidBy"`
+	CategoryID            uint               `json:"category_id" gorm:"not null"`
+	Category              Category           `json:"category" gorm:"foreignKey:CategoryID"`
+	Description           string             `json:"description" gorm:"size:255;not null"`
+	Amount                Money              `json:"amount" gorm:"type:bigint;not null"`
+	TotalAmount           *Money             `json:"total_amount" gorm:"type:bigint"`
+	Type                  RecurringType      `json:"type" gorm:"size:20;not null"`
+	InstallmentCount      *int               `json:"installment_count"`
+	InstallmentsRemaining *int               `json:"installments_remaining"`
+	Principal             *Money             `json:"principal" gorm:"type:bigint"`
+	InterestRate          float64            `json:"interest_rate" gorm:"type:decimal(7,4);not null;default:0"`
+	ScheduleOffset        int                `json:"schedule_offset" gorm:"not null;default:0"`
+	PayoffExpenseID       *uint              `json:"payoff_expense_id"`
+	EstimateWindow        int                `json:"estimate_window" gorm:"not null;default:3"`
+	IsShared              bool               `json:"is_shared" gorm:"default:true"`
+	SplitRatio            float64            `json:"split_ratio" gorm:"type:decimal(5,2)"`
+	Frequency             RecurringFrequency `json:"frequency" gorm:"size:20;not null;default:'monthly'"`
+	Interval              int                `json:"interval" gorm:"column:interval_count;not null;default:1"`
+	DayOfMonth            *int               `json:"day_of_month"`
+	StartDate             time.Time          `json:"start_date" gorm:"type:date;not null"`
+	EndDate               *time.Time         `json:"end_date" gorm:"type:date"`
+	LastGeneratedPeriod   *time.Time         `json:"last_generated_period" gorm:"type:date"`
+	PausedAt              *time.Time         `json:"paused_at" gorm:"type:date"`
+	IsActive              bool               `json:"is_active" gorm:"default:true"`
+	Status                ExpenseStatus      `json:"status" gorm:"size:20;default:'pending'"`
+	ApprovedBy            *uint              `json:"approved_by"`
+	Approver              *User              `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy"`
+	CurrentRevisionID     *uint              `json:"current_revision_id"`
+	CreatedAt             time.Time          `json:"created_at"`
 }
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// RecurringPause şablonun duraklatıldığı aralık [PausedAt, ResumedAt). ResumedAt boşsa
+// şablon hâlâ duraklatılmıştır; aralığa düşen tekrarlar üretilmez.
+type RecurringPause struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	RecurringExpenseID uint       `json:"recurring_expense_id" gorm:"not null;index"`
+	PausedAt           time.Time  `json:"paused_at" gorm:"type:date;not null"`
+	PausedBy           uint       `json:"paused_by" gorm:"not null"`
+	Pauser             User       `json:"pauser" gorm:"foreignKey:PausedBy"`
+	Reason             string     `json:"reason" gorm:"size:255;not null"`
+	ResumedAt          *time.Time `json:"resumed_at" gorm:"type:date"`
+	ResumedBy          *uint      `json:"resumed_by"`
+	Resumer            *User      `json:"resumer,omitempty" gorm:"foreignKey:ResumedBy"`
+	ResumeReason       string     `json:"resume_reason" gorm:"size:255"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// RecurringSkip şablonun tek bir dönemde (ay) atlanması
+type RecurringSkip struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	RecurringExpenseID uint      `json:"recurring_expense_id" gorm:"not null;index"`
+	Month              int       `json:"month" gorm:"column:skip_month;not null"`
+	Year               int       `json:"year" gorm:"column:skip_year;not null"`
+	Reason             string    `json:"reason" gorm:"size:255;not null"`
+	CreatedBy          uint      `json:"created_by" gorm:"not null"`
+	Creator            User      `json:"creator" gorm:"foreignKey:CreatedBy"`
+	CreatedAt          time.Time `json:"created_at"`
+}
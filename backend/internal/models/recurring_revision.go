@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// RecurringRevision şablonun bir sürümü. Onaylanmış bir şablonda yapılan değişiklik yeni
+// bir bekleyen sürüm olarak saklanır; diğer üye onaylayana kadar şablonun mevcut
+// (onaylı) sürümü geçerli kalır ve giderler ondan üretilir. Taksit planının yeniden
+// yapılandırılması da bir sürümdür; bu sürümler ayrıca yeni anaparayı, faiz oranını ve kalan
+// taksit sayısını taşır.
+type RecurringRevision struct {
+	ID                    uint          `json:"id" gorm:"primaryKey"`
+	RecurringExpenseID    uint          `json:"recurring_expense_id" gorm:"not null;index"`
+	Version               int           `json:"version" gorm:"not null"`
+	ProposedBy            uint          `json:"proposed_by" gorm:"not null"`
+	Proposer              User          `json:"proposer" gorm:"foreignKey:ProposedBy"`
+	Status                ExpenseStatus `json:"status" gorm:"size:20;not null"`
+	CategoryID            uint          `json:"category_id" gorm:"not null"`
+	Description           string        `json:"description" gorm:"size:255;not null"`
+	Amount                Money         `json:"amount" gorm:"type:bigint;not null"`
+	TotalAmount           *Money        `json:"total_amount" gorm:"type:bigint"`
+	PaidBy                uint          `json:"paid_by" gorm:"not null"`
+	IsShared              bool          `json:"is_shared"`
+	SplitRatio            float64       `json:"split_ratio" gorm:"type:decimal(5,2)"`
+	Principal             *Money        `json:"principal,omitempty" gorm:"type:bigint"`
+	InterestRate          *float64      `json:"interest_rate,omitempty" gorm:"type:decimal(7,4)"`
+	InstallmentsRemaining *int          `json:"installments_remaining,omitempty"`
+	ReviewedBy            *uint         `json:"reviewed_by"`
+	Reviewer              *User         `json:"reviewer,omitempty" gorm:"foreignKey:ReviewedBy"`
+	ReviewedAt            *time.Time    `json:"reviewed_at"`
+	CreatedAt             time.Time     `json:"created_at"`
+}
@@ -2,15 +2,21 @@ package models
 
 import "time"
 
-// Payment kısmi ödeme kaydı — ay içinde birden fazla ödeme yapılabilir
+// Payment kısmi ödeme kaydı — ay içinde birden fazla ödeme yapılabilir.
+// Ödeme, alacaklı (payee) parayı aldığını onaylayana kadar hesaplaşmaya girmez.
 type Payment struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Month     int       `json:"month" gorm:"not null"`
-	Year      int       `json:"year" gorm:"not null"`
-	PayerID   uint      `json:"payer_id" gorm:"not null"`
-	Payer     User      `json:"payer" gorm:"foreignKey:PayerID"`
-	PayeeID   uint      `json:"payee_id" gorm:"not null"`
-	Payee     User      `json:"payee" gorm:"foreignKey:PayeeID"`
-	Amount    float64   `json:"amount" gorm:"type:decimal(10,2);not null"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                uint          `json:"id" gorm:"primaryKey"`
+	HouseholdID       uint          `json:"household_id" gorm:"not null;index"`
+	Month             int           `json:"month" gorm:"not null"`
+	Year              int           `json:"year" gorm:"not null"`
+	PayerID           uint          `json:"payer_id" gorm:"not null"`
+	Payer             User          `json:"payer" gorm:"foreignKey:PayerID"`
+	PayeeID           uint          `json:"payee_id" gorm:"not null"`
+	Payee             User          `json:"payee" gorm:"foreignKey:PayeeID"`
+	Amount            Money         `json:"amount" gorm:"type:bigint;not null"`
+	Status            ExpenseStatus `json:"status" gorm:"size:20;default:'pending'"`
+	ConfirmedAt       *time.Time    `json:"confirmed_at"`
+	DeleteRequestedBy *uint         `json:"delete_requested_by"`
+	DeleteRequester   *User         `json:"delete_requester,omitempty" gorm:"foreignKey:DeleteRequestedBy"`
+	CreatedAt         time.Time     `json:"created_at"`
 }
@@ -1,22 +1,27 @@
 package scheduler
 
 import (
+	"errors"
 	"log"
 
 	"github.com/caner/home-gider/internal/services"
 	"github.com/robfig/cron/v3"
 )
 
-func Start(recurringService *services.RecurringService) *cron.Cron {
+func Start(recurringService *services.RecurringService, jobService *services.JobService) *cron.Cron {
 	c := cron.New()
 
 	// Her gün saat 00:05'te taksit ve sabit giderleri kontrol et
 	c.AddFunc("5 0 * * *", func() {
 		log.Println("Taksit/sabit gider kontrolü başlatıldı...")
-		if err := recurringService.ProcessRecurring(); err != nil {
+		run, err := jobService.Run(services.JobRecurring, services.TriggerCron, 0, recurringService.ProcessRecurring)
+		switch {
+		case errors.Is(err, services.ErrJobRunning):
+			log.Println("Taksit/sabit gider işi başka bir replikada çalışıyor, atlandı")
+		case err != nil:
 			log.Printf("Taksit/sabit gider işleme hatası: %v", err)
-		} else {
-			log.Println("Taksit/sabit gider kontrolü tamamlandı")
+		default:
+			log.Printf("Taksit/sabit gider kontrolü tamamlandı (%d gider oluşturuldu)", run.ItemsCreated)
 		}
 	})
 
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+// Audit kayıtlarındaki varlık tipleri
+const (
+	auditExpense   = "expense"
+	auditRecurring = "recurring_expense"
+	auditPayment   = "payment"
+	auditUser      = "user"
+	auditHousehold = "household"
+)
+
+// recordAudit işlemi yapan transaction içinde değişmez bir kayıt ekler; böylece kayıt
+// ancak işlem başarılı olursa yazılır. householdID veya actorID 0 ise boş bırakılır.
+func recordAudit(tx *gorm.DB, householdID, actorID uint, entity string, entityID uint, action models.AuditAction, before, after interface{}) error {
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+	event := models.AuditEvent{
+		EntityType: entity,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    changes,
+	}
+	if householdID != 0 {
+		event.HouseholdID = &householdID
+	}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+	return tx.Create(&event).Error
+}
+
+// auditDiff iki kaydın JSON görünümünü karşılaştırır ve sadece değişen alanları döner.
+// İlişkili nesneler (creator, payer gibi) karşılaştırmaya girmez; sadece id alanları yeterli.
+func auditDiff(before, after interface{}) (models.AuditChanges, error) {
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := models.AuditChanges{}
+	for key, av := range a {
+		bv, ok := b[key]
+		if !ok || !reflect.DeepEqual(av, bv) {
+			changes[key] = models.AuditChange{Before: bv, After: av}
+		}
+	}
+	for key, bv := range b {
+		if _, ok := a[key]; !ok {
+			changes[key] = models.AuditChange{Before: bv}
+		}
+	}
+	return changes, nil
+}
+
+// auditFields kaydı düz alan haritasına çevirir
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if v == nil {
+		return fields, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return fields, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			delete(fields, key)
+		case []interface{}:
+			// Paylar gibi alt kayıtlarda da iç içe nesneler atılır
+			for _, item := range value {
+				if obj, ok := item.(map[string]interface{}); ok {
+					for k, nested := range obj {
+						if _, isObj := nested.(map[string]interface{}); isObj {
+							delete(obj, k)
+						}
+					}
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// AuditFilter audit kayıtlarını daraltmak için; sıfır değerli alanlar yok sayılır
+type AuditFilter struct {
+	EntityType string
+	EntityID   uint
+	ActorID    uint
+	Action     string
+	From       *time.Time
+	To         *time.Time
+	BeforeID   uint
+	Limit      int
+}
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 500
+)
+
+func (f AuditFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.EntityType != "" {
+		db = db.Where("entity_type = ?", f.EntityType)
+	}
+	if f.EntityID != 0 {
+		db = db.Where("entity_id = ?", f.EntityID)
+	}
+	if f.ActorID != 0 {
+		db = db.Where("actor_id = ?", f.ActorID)
+	}
+	if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	if f.From != nil {
+		db = db.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("created_at < ?", *f.To)
+	}
+	// Sayfalama: önceki sayfanın en küçük id'sinden geriye doğru
+	if f.BeforeID != 0 {
+		db = db.Where("id < ?", f.BeforeID)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	if limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+	return db.Preload("Actor").Order("id DESC").Limit(limit)
+}
+
+// List hanenin audit kayıtlarını en yeniden eskiye döner
+func (s *AuditService) List(householdID uint, filter AuditFilter) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	err := s.db.Scopes(inHousehold(householdID), filter.apply).Find(&events).Error
+	return events, err
+}
+
+// ListAll admin için hane dışı kayıtlar (şifre sıfırlama gibi) dahil tüm kayıtlar
+func (s *AuditService) ListAll(filter AuditFilter) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	err := s.db.Scopes(filter.apply).Find(&events).Error
+	return events, err
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AuthService struct {
@@ -30,6 +31,58 @@ func (s *AuthService) Login(username, password string) (*models.User, string, er
 		return nil, "", err
 	}
 
+	membership, err := s.defaultMembership(user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tokenStr, err := s.issueToken(&user, membership)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &user, tokenStr, nil
+}
+
+// SwitchHousehold kullanıcının üyesi olduğu başka bir hane için yeni token üretir
+func (s *AuthService) SwitchHousehold(userID, householdID uint) (*models.User, string, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, "", err
+	}
+
+	var membership models.HouseholdMember
+	err := s.db.Where("user_id = ? AND household_id = ?", userID, householdID).First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", errors.New("bu hanenin üyesi değilsiniz")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	tokenStr, err := s.issueToken(&user, &membership)
+	if err != nil {
+		return nil, "", err
+	}
+	return &user, tokenStr, nil
+}
+
+// defaultMembership kullanıcının ilk katıldığı haneyi döner; hiçbir haneye üye değilse nil
+func (s *AuthService) defaultMembership(userID uint) (*models.HouseholdMember, error) {
+	var membership models.HouseholdMember
+	err := s.db.Where("user_id = ?", userID).
+		Order("created_at ASC, household_id ASC").
+		First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (s *AuthService) issueToken(user *models.User, membership *models.HouseholdMember) (string, error) {
 	claims := &middleware.JWTClaims{
 		UserID:      user.ID,
 		Username:    user.Username,
@@ -40,13 +93,85 @@ func (s *AuthService) Login(username, password string) (*models.User, string, er
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
+	if membership != nil {
+		claims.HouseholdID = membership.HouseholdID
+		claims.HouseholdRole = string(membership.Role)
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString([]byte(s.secret))
+	return token.SignedString([]byte(s.secret))
+}
+
+// Register davet koduyla yeni kullanıcı oluşturur ve daveti gönderen haneye üye yapar.
+// Davet satırı kilitlenir; aynı kod iki kez kullanılamaz.
+func (s *AuthService) Register(inviteToken, username, displayName, password string) (*models.User, string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, "", err
 	}
 
+	var user models.User
+	var membership models.HouseholdMember
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var invite models.Invite
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashInviteToken(inviteToken)).
+			First(&invite).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidInvite
+		}
+		if err != nil {
+			return err
+		}
+		if invite.UsedAt != nil || invite.RevokedAt != nil || time.Now().After(invite.ExpiresAt) {
+			return errInvalidInvite
+		}
+
+		var count int64
+		tx.Model(&models.User{}).Where("username = ?", username).Count(&count)
+		if count > 0 {
+			return errors.New("bu kullanıcı adı zaten alınmış")
+		}
+
+		user = models.User{
+			Username:           username,
+			PasswordHash:       string(hash),
+			DisplayName:        displayName,
+			IsAdmin:            false,
+			MustChangePassword: false,
+		}
+		// GORM bool default'u false değeri atlamasın diye alan açıkça seçilir
+		if err := tx.Select("*").Omit("id").Create(&user).Error; err != nil {
+			return err
+		}
+
+		membership = models.HouseholdMember{
+			HouseholdID: invite.HouseholdID,
+			UserID:      user.ID,
+			Role:        models.RoleMember,
+		}
+		if err := tx.Create(&membership).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err = tx.Model(&invite).Updates(map[string]interface{}{
+			"used_by": user.ID,
+			"used_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, invite.HouseholdID, user.ID, auditUser, user.ID, models.AuditRegister, nil, &user)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	tokenStr, err := s.issueToken(&user, &membership)
+	if err != nil {
+		return nil, "", err
+	}
 	return &user, tokenStr, nil
 }
 
@@ -63,10 +188,27 @@ func (s *AuthService) ChangePassword(userID uint, newPassword string) error {
 	if err != nil {
 		return err
 	}
-	return s.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
-		"password_hash":        string(hash),
-		"must_change_password": false,
-	}).Error
+	return s.setPassword(userID, userID, string(hash), false, models.AuditPasswordChange)
+}
+
+// setPassword şifreyi değiştirir; hash audit kaydına girmez, sadece işlemin kendisi kaydedilir
+func (s *AuthService) setPassword(actorID, userID uint, hash string, mustChange bool, action models.AuditAction) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.User
+		if err := tx.First(&before, userID).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password_hash":        hash,
+			"must_change_password": mustChange,
+		}).Error
+		if err != nil {
+			return err
+		}
+		after := before
+		after.MustChangePassword = mustChange
+		return recordAudit(tx, 0, actorID, auditUser, userID, action, &before, &after)
+	})
 }
 
 // AdminResetPassword admin kullanıcının başka bir kullanıcının şifresini sıfırlaması
@@ -83,10 +225,7 @@ func (s *AuthService) AdminResetPassword(adminID, targetUserID uint, newPassword
 	if err != nil {
 		return err
 	}
-	return s.db.Model(&models.User{}).Where("id = ?", targetUserID).Updates(map[string]interface{}{
-		"password_hash":        string(hash),
-		"must_change_password": true,
-	}).Error
+	return s.setPassword(adminID, targetUserID, string(hash), true, models.AuditPasswordReset)
 }
 
 // ListUsers admin için tüm kullanıcıları listele
@@ -16,60 +16,249 @@ func NewExpenseService(db *gorm.DB) *ExpenseService {
 	return &ExpenseService{db: db}
 }
 
-func (s *ExpenseService) List(month, year int) ([]models.Expense, error) {
-	var expenses []models.Expense
-	err := s.db.
-		Preload("Creator").
-		Preload("Category").
-		Preload("Approver").
-		Preload("DeleteRequester").
-		Where("expense_month = ? AND expense_year = ?", month, year).
-		Order("created_at DESC").
-		Find(&expenses).Error
-	return expenses, err
-}
-
-func (s *ExpenseService) Create(expense *models.Expense) error {
+// Create gideri paylarıyla birlikte kaydeder
+func (s *ExpenseService) Create(expense *models.Expense, spec SplitSpec) error {
 	if !expense.IsShared {
 		expense.Status = models.StatusApproved
 	}
+	if expense.PaidBy == 0 {
+		expense.PaidBy = expense.CreatedBy
+	}
+	cal, err := householdCalendar(s.db, expense.HouseholdID)
+	if err != nil {
+		return err
+	}
+	// Tarih verilmezse bugün; başka bir dönem istendiyse o dönemin ilk günü
+	if expense.ExpenseDate.IsZero() {
+		expense.ExpenseDate = cal.today()
+		m, y := cal.periodOf(expense.ExpenseDate)
+		if expense.ExpenseMonth != 0 && (expense.ExpenseMonth != m || expense.ExpenseYear != y) {
+			expense.ExpenseDate, _ = cal.bounds(expense.ExpenseMonth, expense.ExpenseYear)
+		}
+	}
+	// Dönem verilmezse gider tarihinden çıkar
+	expense.ExpenseDate = dateOnly(expense.ExpenseDate)
+	month, year, err := expensePeriod(cal, expense.ExpenseDate, expense.ExpenseMonth, expense.ExpenseYear)
+	if err != nil {
+		return &ValidationError{Fields: map[string]string{"month": err.Error()}}
+	}
+	expense.ExpenseMonth, expense.ExpenseYear = month, year
+	if err := ensurePayer(s.db, expense.HouseholdID, expense.PaidBy); err != nil {
+		return err
+	}
 
-	return s.db.Create(expense).Error
+	mode, splits, err := buildSplits(s.db, expense.HouseholdID, expense.CreatedBy, expense.Amount, expense.IsShared, spec)
+	if err != nil {
+		return err
+	}
+	expense.SplitMode = mode
+	expense.Splits = splits
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, expense.HouseholdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		if err := tx.Create(expense).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, expense.HouseholdID, expense.CreatedBy, auditExpense, expense.ID, models.AuditCreate, nil, expense)
+	})
 }
 
-func (s *ExpenseService) Update(id, userID uint, updates map[string]interface{}) error {
+// Update onay bekleyen gideri düzenler; sadece ExpenseChanges alanları değiştirilebilir
+func (s *ExpenseService) Update(householdID, id, userID uint, changes ExpenseChanges) error {
 	var expense models.Expense
-	if err := s.db.First(&expense, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).Preload("Splits").First(&expense, id).Error; err != nil {
 		return err
 	}
 	if expense.CreatedBy != userID {
 		return errors.New("sadece kendi eklediğiniz giderleri düzenleyebilirsiniz")
 	}
 	if expense.Status != models.StatusPending {
-		return errors.New("sadece onay bekleyen giderler düzenlenebilir")
+		return errors.New("sadece onay bekleyen giderler düzenlenebilir; onaylanmış gider için değişiklik önerin")
+	}
+	if err := changes.validate(s.db, householdID); err != nil {
+		return err
 	}
-	return s.db.Model(&expense).Updates(updates).Error
+	updates := changes.columns()
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return err
+	}
+	date, month, year, err := changes.dated(cal, expense.ExpenseDate, expense.ExpenseMonth, expense.ExpenseYear)
+	if err != nil {
+		return err
+	}
+	if changes.Date != nil || changes.Month != nil || changes.Year != nil {
+		updates["expense_date"] = date
+		updates["expense_month"] = month
+		updates["expense_year"] = year
+	}
+
+	// Bölüşüm değişmediyse mevcut tanım yeni tutara göre yeniden uygulanır
+	spec := changes.splitSpec(splitSpecFrom(expense.SplitMode, expense.Splits))
+
+	before := expense
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		if err := ensureBaseline(tx, &before); err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&expense).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		// Gider başka bir aya taşındıysa hedef ay da açık olmalı
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		if err := resplit(tx, &expense, spec); err != nil {
+			return err
+		}
+
+		var after models.Expense
+		if err := tx.Preload("Splits").First(&after, expense.ID).Error; err != nil {
+			return err
+		}
+		// Onay bekleyen giderde düzenleme doğrudan yeni sürüm olur
+		rev := revisionFrom(&after)
+		rev.ProposedBy = userID
+		rev.Status = models.StatusApproved
+		if err := saveRevision(tx, &rev); err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditUpdate, &before, &after)
+	})
 }
 
-func (s *ExpenseService) Delete(id, userID uint, isAdmin bool) error {
+// ensurePayer ödeyen kişinin hanenin üyesi olduğunu doğrular
+func ensurePayer(db *gorm.DB, householdID, payerID uint) error {
+	member, err := isHouseholdMember(db, householdID, payerID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return errors.New("ödeyen kişi bu hanenin üyesi değil")
+	}
+	return nil
+}
+
+// resplit giderin güncel tutarı ve tanıma göre payları baştan yazar
+func resplit(tx *gorm.DB, expense *models.Expense, spec SplitSpec) error {
+	var current models.Expense
+	if err := tx.First(&current, expense.ID).Error; err != nil {
+		return err
+	}
+	mode, splits, err := buildSplits(tx, current.HouseholdID, current.CreatedBy, current.Amount, current.IsShared, spec)
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("expense_id = ?", current.ID).Delete(&models.ExpenseSplit{}).Error; err != nil {
+		return err
+	}
+	for i := range splits {
+		splits[i].ExpenseID = current.ID
+	}
+	if err := tx.Create(&splits).Error; err != nil {
+		return err
+	}
+	return tx.Model(&current).Update("split_mode", mode).Error
+}
+
+// SetActualAmount değişken tutarlı faturanın tahmini tutarını ödeyenin girdiği gerçek tutarla
+// değiştirir. Paylar yeni tutara göre yeniden hesaplanır; gider diğer üyenin onayını bekler.
+// Yeni sürüm de onay bekler ve giderle birlikte onaylanır ya da reddedilir.
+func (s *ExpenseService) SetActualAmount(householdID, id, userID uint, amount models.Money) error {
 	var expense models.Expense
-	if err := s.db.First(&expense, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).Preload("Splits").First(&expense, id).Error; err != nil {
+		return err
+	}
+	if expense.PaidBy != userID {
+		return errors.New("gerçek tutarı sadece ödeyen kişi girebilir")
+	}
+	if !expense.IsEstimate || expense.Status != models.StatusPending {
+		return errors.New("bu gider tahmini tutarlı bir fatura değil")
+	}
+	if amount <= 0 {
+		return &ValidationError{Fields: map[string]string{"amount": "tutar sıfırdan büyük olmalı"}}
+	}
+
+	before := expense
+	spec := splitSpecFrom(expense.SplitMode, expense.Splits)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		if err := ensureBaseline(tx, &before); err != nil {
+			return err
+		}
+		err := tx.Model(&expense).Updates(map[string]interface{}{
+			"amount":        amount,
+			"is_estimate":   false,
+			"actual_set_by": userID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := resplit(tx, &expense, spec); err != nil {
+			return err
+		}
+
+		var after models.Expense
+		if err := tx.Preload("Splits").First(&after, expense.ID).Error; err != nil {
+			return err
+		}
+		rev := revisionFrom(&after)
+		rev.ProposedBy = userID
+		rev.Status = models.StatusPending
+		if err := saveRevision(tx, &rev); err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditUpdate, &before, &after)
+	})
+}
+
+func (s *ExpenseService) Delete(householdID, id, userID uint, isAdmin bool) error {
+	var expense models.Expense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&expense, id).Error; err != nil {
 		return err
 	}
 	// Admin direkt silebilir
 	if isAdmin {
-		return s.db.Delete(&expense).Error
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+				return err
+			}
+			if err := tx.Delete(&expense).Error; err != nil {
+				return err
+			}
+			return recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditDelete, &expense, nil)
+		})
 	}
 	// Normal kullanıcı: silme talep et
 	if expense.DeleteRequestedBy != nil {
 		return errors.New("bu gider için zaten silme talebi var")
 	}
-	return s.db.Model(&expense).Update("delete_requested_by", userID).Error
+	before := expense
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		if err := tx.Model(&expense).Update("delete_requested_by", userID).Error; err != nil {
+			return err
+		}
+		after := before
+		after.DeleteRequestedBy = &userID
+		return recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditDeleteRequest, &before, &after)
+	})
 }
 
-func (s *ExpenseService) ConfirmDelete(id, userID uint) error {
+func (s *ExpenseService) ConfirmDelete(householdID, id, userID uint) error {
 	var expense models.Expense
-	if err := s.db.First(&expense, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).First(&expense, id).Error; err != nil {
 		return err
 	}
 	if expense.DeleteRequestedBy == nil {
@@ -78,43 +267,99 @@ func (s *ExpenseService) ConfirmDelete(id, userID uint) error {
 	if *expense.DeleteRequestedBy == userID {
 		return errors.New("kendi silme talebinizi onaylayamazsınız")
 	}
-	return s.db.Delete(&expense).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		if err := tx.Delete(&expense).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditDelete, &expense, nil)
+	})
 }
 
-func (s *ExpenseService) CancelDelete(id, userID uint) error {
+func (s *ExpenseService) CancelDelete(householdID, id, userID uint) error {
 	var expense models.Expense
-	if err := s.db.First(&expense, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).First(&expense, id).Error; err != nil {
 		return err
 	}
 	if expense.DeleteRequestedBy == nil {
 		return errors.New("bu gider için silme talebi yok")
 	}
 	// Hem talep eden hem karşı taraf iptal/reddet yapabilir
-	return s.db.Model(&expense).Update("delete_requested_by", nil).Error
+	before := expense
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&expense).Update("delete_requested_by", nil).Error; err != nil {
+			return err
+		}
+		after := before
+		after.DeleteRequestedBy = nil
+		return recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditDeleteCancel, &before, &after)
+	})
 }
 
-func (s *ExpenseService) Approve(id, approverID uint, isAdmin bool) error {
+// approvalError giderin bu kişi tarafından onaylanıp onaylanamayacağını söyler. Onay kuralı
+// ödeyene göre değil, kaydı girene göre işler. Tahmini faturada onaylanan tutar gerçek tutarı
+// girenindir; bu durumda yalnızca o kişi onaylayamaz, şablonu açan diğer üye onaylayabilir.
+func approvalError(expense *models.Expense, approverID uint, isAdmin bool) error {
+	switch {
+	case isAdmin:
+		return nil
+	case expense.ActualSetBy != nil:
+		if *expense.ActualSetBy == approverID {
+			return errors.New("gerçek tutarını kendiniz girdiğiniz faturayı onaylayamazsınız")
+		}
+	case expense.CreatedBy == approverID:
+		return errors.New("kendi eklediğiniz gideri onaylayamazsınız")
+	}
+	return nil
+}
+
+func (s *ExpenseService) Approve(householdID, id, approverID uint, isAdmin bool) error {
 	var expense models.Expense
-	if err := s.db.First(&expense, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).First(&expense, id).Error; err != nil {
 		return err
 	}
-	if !isAdmin && expense.CreatedBy == approverID {
-		return errors.New("kendi eklediğiniz gideri onaylayamazsınız")
+	if err := approvalError(&expense, approverID, isAdmin); err != nil {
+		return err
 	}
 	if expense.Status != models.StatusPending {
 		return errors.New("bu gider zaten işlenmiş")
 	}
+	if expense.IsEstimate {
+		return errors.New("faturanın gerçek tutarı henüz girilmedi")
+	}
 	now := time.Now()
-	return s.db.Model(&expense).Updates(map[string]interface{}{
-		"status":      models.StatusApproved,
-		"approved_by": approverID,
-		"approved_at": now,
-	}).Error
+	before := expense
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		err := tx.Model(&expense).Updates(map[string]interface{}{
+			"status":      models.StatusApproved,
+			"approved_by": approverID,
+			"approved_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := reviewPendingRevisions(tx, expense.ID, models.StatusApproved, approverID, now); err != nil {
+			return err
+		}
+		if err := settlePayoff(tx, &expense, true, approverID); err != nil {
+			return err
+		}
+		after := before
+		after.Status = models.StatusApproved
+		after.ApprovedBy = &approverID
+		after.ApprovedAt = &now
+		return recordAudit(tx, householdID, approverID, auditExpense, expense.ID, models.AuditApprove, &before, &after)
+	})
 }
 
-func (s *ExpenseService) Reject(id, userID uint, isAdmin bool) error {
+func (s *ExpenseService) Reject(householdID, id, userID uint, isAdmin bool) error {
 	var expense models.Expense
-	if err := s.db.First(&expense, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).First(&expense, id).Error; err != nil {
 		return err
 	}
 	if !isAdmin && expense.CreatedBy == userID {
@@ -123,11 +368,28 @@ func (s *ExpenseService) Reject(id, userID uint, isAdmin bool) error {
 	if expense.Status != models.StatusPending {
 		return errors.New("bu gider zaten işlenmiş")
 	}
-	return s.db.Model(&expense).Update("status", models.StatusRejected).Error
+	before := expense
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		if err := tx.Model(&expense).Update("status", models.StatusRejected).Error; err != nil {
+			return err
+		}
+		if err := reviewPendingRevisions(tx, expense.ID, models.StatusRejected, userID, time.Now()); err != nil {
+			return err
+		}
+		if err := settlePayoff(tx, &expense, false, userID); err != nil {
+			return err
+		}
+		after := before
+		after.Status = models.StatusRejected
+		return recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditReject, &before, &after)
+	})
 }
 
-func (s *ExpenseService) GetByID(id uint) (*models.Expense, error) {
+func (s *ExpenseService) GetByID(householdID, id uint) (*models.Expense, error) {
 	var expense models.Expense
-	err := s.db.Preload("Creator").Preload("Category").Preload("Approver").Preload("DeleteRequester").First(&expense, id).Error
+	err := s.db.Scopes(inHousehold(householdID)).Preload("Creator").Preload("Payer").Preload("Category").Preload("Approver").Preload("DeleteRequester").Preload("Splits").First(&expense, id).Error
 	return &expense, err
 }
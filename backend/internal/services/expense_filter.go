@@ -0,0 +1,283 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultExpenseLimit = 50
+	maxExpenseLimit     = 200
+)
+
+// expenseSort listenin sıralama seçeneği; eşitlikte id ile aynı yönde sıralanır
+type expenseSort struct {
+	column string
+	desc   bool
+}
+
+var expenseSorts = map[string]expenseSort{
+	"date_desc":    {"expense_date", true},
+	"date_asc":     {"expense_date", false},
+	"amount_desc":  {"amount", true},
+	"amount_asc":   {"amount", false},
+	"created_desc": {"created_at", true},
+	"created_asc":  {"created_at", false},
+}
+
+// key giderin sıralama sütunundaki değeri; imlece yazılır
+func (o expenseSort) key(e *models.Expense) string {
+	switch o.column {
+	case "amount":
+		return strconv.FormatInt(int64(e.Amount), 10)
+	case "created_at":
+		return e.CreatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return dateOnly(e.ExpenseDate).Format("2006-01-02")
+	}
+}
+
+// parseKey imleçteki değeri sütun tipine çevirir
+func (o expenseSort) parseKey(key string) (interface{}, error) {
+	switch o.column {
+	case "amount":
+		return strconv.ParseInt(key, 10, 64)
+	case "created_at":
+		return time.Parse(time.RFC3339Nano, key)
+	default:
+		return time.Parse("2006-01-02", key)
+	}
+}
+
+// expenseCursor bir sonraki sayfanın başladığı yer: önceki sayfanın son kaydı
+type expenseCursor struct {
+	Key string `json:"k"`
+	ID  uint   `json:"id"`
+}
+
+func encodeCursor(c expenseCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (expenseCursor, bool) {
+	var c expenseCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.ID == 0 {
+		return c, false
+	}
+	return c, true
+}
+
+// ExpenseFilter gider listesi filtresi; sıfır değerli alanlar yok sayılır. From/To verilirse
+// gider tarihine göre (iki uç dahil), verilmezse döneme göre süzülür; dönem de boşsa bugünün
+// dönemi kullanılır. AllPeriods tüm dönemlerde arar. Limit ve Cursor boşsa sayfalama yapılmaz,
+// filtreye uyan tüm giderler döner. Paylar ve silme talebi eden kişi yalnızca istenirse yüklenir.
+type ExpenseFilter struct {
+	Month           int
+	Year            int
+	From            *time.Time
+	To              *time.Time
+	AllPeriods      bool
+	Query           string
+	CategoryID      uint
+	CreatedBy       uint
+	PaidBy          uint
+	Status          models.ExpenseStatus
+	Shared          *bool
+	MinAmount       *models.Money
+	MaxAmount       *models.Money
+	Recurring       *bool
+	Installment     *bool
+	DeleteRequested *bool
+	Sort            string
+	Cursor          string
+	Limit           int
+	WithSplits      bool
+	WithRequester   bool
+}
+
+// paged sayfalama istenmiş mi?
+func (f ExpenseFilter) paged() bool {
+	return f.Limit != 0 || f.Cursor != ""
+}
+
+func (f *ExpenseFilter) validate() error {
+	var v ValidationError
+	if f.Month != 0 || f.Year != 0 {
+		if err := validPeriod(f.Month, f.Year); err != nil {
+			v.add("month", err.Error())
+		}
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		v.add("to", "bitiş tarihi başlangıçtan önce olamaz")
+	}
+	switch f.Status {
+	case "", models.StatusPending, models.StatusApproved, models.StatusRejected:
+	default:
+		v.add("status", "geçersiz durum")
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MaxAmount < *f.MinAmount {
+		v.add("max_amount", "en yüksek tutar en düşükten küçük olamaz")
+	}
+	if f.Sort == "" {
+		f.Sort = "date_desc"
+	}
+	sort, ok := expenseSorts[f.Sort]
+	if !ok {
+		v.add("sort", "geçersiz sıralama")
+	} else if f.Cursor != "" {
+		c, ok := decodeCursor(f.Cursor)
+		if ok {
+			_, err := sort.parseKey(c.Key)
+			ok = err == nil
+		}
+		if !ok {
+			v.add("cursor", "geçersiz imleç")
+		}
+	}
+	if f.paged() && f.Limit <= 0 {
+		f.Limit = defaultExpenseLimit
+	}
+	if f.Limit > maxExpenseLimit {
+		f.Limit = maxExpenseLimit
+	}
+	return v.result()
+}
+
+// likePattern metni ILIKE içinde birebir aranacak biçime getirir
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+func optional(db *gorm.DB, flag *bool, when, otherwise string) *gorm.DB {
+	if flag == nil {
+		return db
+	}
+	if *flag {
+		return db.Where(when)
+	}
+	return db.Where(otherwise)
+}
+
+// apply sayfalama dışındaki tüm koşulları uygular; toplamlar da bu koşullarla hesaplanır
+func (f ExpenseFilter) apply(db *gorm.DB) *gorm.DB {
+	switch {
+	case f.From != nil || f.To != nil:
+		if f.From != nil {
+			db = db.Where("expense_date >= ?", dateOnly(*f.From))
+		}
+		if f.To != nil {
+			db = db.Where("expense_date <= ?", dateOnly(*f.To))
+		}
+	case !f.AllPeriods:
+		db = db.Scopes(inPeriod(f.Month, f.Year))
+	}
+	if q := strings.TrimSpace(f.Query); q != "" {
+		db = db.Where("description ILIKE ?", likePattern(q))
+	}
+	if f.CategoryID != 0 {
+		db = db.Where("category_id = ?", f.CategoryID)
+	}
+	if f.CreatedBy != 0 {
+		db = db.Where("created_by = ?", f.CreatedBy)
+	}
+	if f.PaidBy != 0 {
+		db = db.Where("paid_by = ?", f.PaidBy)
+	}
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	if f.MinAmount != nil {
+		db = db.Where("amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		db = db.Where("amount <= ?", *f.MaxAmount)
+	}
+	db = optional(db, f.Shared, "is_shared = true", "is_shared = false")
+	db = optional(db, f.Recurring, "recurring_expense_id IS NOT NULL", "recurring_expense_id IS NULL")
+	db = optional(db, f.Installment, "is_installment = true", "is_installment = false")
+	db = optional(db, f.DeleteRequested, "delete_requested_by IS NOT NULL", "delete_requested_by IS NULL")
+	return db
+}
+
+// page imleçten sonraki kayıtları seçilen sırayla okur. Bir sonraki sayfa olup olmadığını
+// anlamak için bir kayıt fazla istenir.
+func (f ExpenseFilter) page(db *gorm.DB) *gorm.DB {
+	sort := expenseSorts[f.Sort]
+	dir, cmp := "ASC", ">"
+	if sort.desc {
+		dir, cmp = "DESC", "<"
+	}
+	if c, ok := decodeCursor(f.Cursor); ok {
+		key, _ := sort.parseKey(c.Key)
+		db = db.Where("("+sort.column+" "+cmp+" ? OR ("+sort.column+" = ? AND id "+cmp+" ?))", key, key, c.ID)
+	}
+	db = db.Order(sort.column + " " + dir + ", id " + dir)
+	if !f.paged() {
+		return db
+	}
+	return db.Limit(f.Limit + 1)
+}
+
+// ExpensePage giderlerin bir sayfası. Toplamlar sayfaya değil filtreye uyan tüm giderlere
+// aittir; NextCursor boşsa son sayfadır.
+type ExpensePage struct {
+	Items       []models.Expense
+	TotalCount  int64
+	TotalAmount models.Money
+	NextCursor  string
+}
+
+// List filtreye uyan giderleri sayfa sayfa, toplam adet ve tutarla döner
+func (s *ExpenseService) List(householdID uint, filter ExpenseFilter) (*ExpensePage, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
+	filter.Month, filter.Year = cal.resolve(filter.Month, filter.Year)
+
+	result := &ExpensePage{Items: []models.Expense{}}
+	var totals struct {
+		Count  int64
+		Amount models.Money
+	}
+	err = s.db.Model(&models.Expense{}).Scopes(inHousehold(householdID), filter.apply).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0)::BIGINT AS amount").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	result.TotalCount, result.TotalAmount = totals.Count, totals.Amount
+
+	query := s.db.Scopes(inHousehold(householdID), filter.apply, filter.page).
+		Preload("Creator").
+		Preload("Payer").
+		Preload("Category").
+		Preload("Approver")
+	if filter.WithRequester {
+		query = query.Preload("DeleteRequester")
+	}
+	if filter.WithSplits {
+		query = query.Preload("Splits")
+	}
+	if err := query.Find(&result.Items).Error; err != nil {
+		return nil, err
+	}
+	if filter.paged() && len(result.Items) > filter.Limit {
+		result.Items = result.Items[:filter.Limit]
+		last := &result.Items[len(result.Items)-1]
+		result.NextCursor = encodeCursor(expenseCursor{Key: expenseSorts[filter.Sort].key(last), ID: last.ID})
+	}
+	return result, nil
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+const auditRevision = "expense_revision"
+
+// revisionFrom giderin mevcut hâlinden bir sürüm üretir
+func revisionFrom(expense *models.Expense) models.ExpenseRevision {
+	splits := make(models.RevisionSplits, 0, len(expense.Splits))
+	for _, sp := range expense.Splits {
+		splits = append(splits, models.RevisionSplit{
+			UserID:     sp.UserID,
+			Shares:     sp.Shares,
+			Percentage: sp.Percentage,
+			Amount:     sp.Amount,
+		})
+	}
+	return models.ExpenseRevision{
+		ExpenseID:    expense.ID,
+		CategoryID:   expense.CategoryID,
+		Description:  expense.Description,
+		Amount:       expense.Amount,
+		PaidBy:       expense.PaidBy,
+		ExpenseDate:  expense.ExpenseDate,
+		ExpenseMonth: expense.ExpenseMonth,
+		ExpenseYear:  expense.ExpenseYear,
+		IsShared:     expense.IsShared,
+		SplitMode:    expense.SplitMode,
+		Splits:       splits,
+	}
+}
+
+// revisionSpec sürümdeki payları yeniden bölüşüm tanımına çevirir
+func revisionSpec(rev *models.ExpenseRevision) SplitSpec {
+	splits := make([]models.ExpenseSplit, 0, len(rev.Splits))
+	for _, sp := range rev.Splits {
+		splits = append(splits, models.ExpenseSplit{
+			UserID:     sp.UserID,
+			Shares:     sp.Shares,
+			Percentage: sp.Percentage,
+			Amount:     sp.Amount,
+		})
+	}
+	return splitSpecFrom(rev.SplitMode, splits)
+}
+
+// ensureBaseline gider için hiç sürüm yoksa ilk hâlini 1. sürüm olarak saklar
+func ensureBaseline(tx *gorm.DB, expense *models.Expense) error {
+	var count int64
+	if err := tx.Model(&models.ExpenseRevision{}).Where("expense_id = ?", expense.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	base := revisionFrom(expense)
+	base.Version = 1
+	base.ProposedBy = expense.CreatedBy
+	base.Status = models.StatusApproved
+	base.CreatedAt = expense.CreatedAt
+	return tx.Create(&base).Error
+}
+
+// saveRevision sürümü bir sonraki numarayla kaydeder
+func saveRevision(tx *gorm.DB, rev *models.ExpenseRevision) error {
+	var last int
+	err := tx.Model(&models.ExpenseRevision{}).
+		Select("COALESCE(MAX(version), 0)").
+		Where("expense_id = ?", rev.ExpenseID).
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	rev.Version = last + 1
+	return tx.Create(rev).Error
+}
+
+// ProposeChange onaylanmış gider için değişiklik önerisi oluşturur. Öneri diğer üye
+// onaylayana kadar gideri etkilemez.
+func (s *ExpenseService) ProposeChange(householdID, id, userID uint, changes ExpenseChanges) (*models.ExpenseRevision, error) {
+	var expense models.Expense
+	if err := s.db.Scopes(inHousehold(householdID)).Preload("Splits").First(&expense, id).Error; err != nil {
+		return nil, err
+	}
+	if expense.CreatedBy != userID {
+		return nil, errors.New("sadece kendi eklediğiniz giderler için değişiklik önerebilirsiniz")
+	}
+	if expense.Status != models.StatusApproved {
+		return nil, errors.New("değişiklik önerisi sadece onaylanmış giderler için yapılabilir")
+	}
+	if expense.DeleteRequestedBy != nil {
+		return nil, errors.New("silme talebi olan gider için değişiklik önerilemez")
+	}
+
+	if err := changes.validate(s.db, householdID); err != nil {
+		return nil, err
+	}
+
+	rev := revisionFrom(&expense)
+	if changes.CategoryID != nil {
+		rev.CategoryID = *changes.CategoryID
+	}
+	if changes.Description != nil {
+		rev.Description = strings.TrimSpace(*changes.Description)
+	}
+	if changes.Amount != nil {
+		rev.Amount = *changes.Amount
+	}
+	if changes.PaidBy != nil {
+		rev.PaidBy = *changes.PaidBy
+	}
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
+	date, month, year, err := changes.dated(cal, expense.ExpenseDate, expense.ExpenseMonth, expense.ExpenseYear)
+	if err != nil {
+		return nil, err
+	}
+	rev.ExpenseDate, rev.ExpenseMonth, rev.ExpenseYear = date, month, year
+	if changes.IsShared != nil {
+		rev.IsShared = *changes.IsShared
+	}
+	spec := changes.splitSpec(splitSpecFrom(expense.SplitMode, expense.Splits))
+	mode, splits, err := buildSplits(s.db, householdID, expense.CreatedBy, rev.Amount, rev.IsShared, spec)
+	if err != nil {
+		return nil, err
+	}
+	rev.SplitMode = mode
+	rev.Splits = revisionFrom(&models.Expense{Splits: splits}).Splits
+	rev.ProposedBy = userID
+	rev.Status = models.StatusPending
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Gider başka bir aya taşınacaksa hedef ay da açık olmalı
+		if err := ensurePeriodOpen(tx, householdID, rev.ExpenseMonth, rev.ExpenseYear); err != nil {
+			return err
+		}
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		var pending int64
+		err := tx.Model(&models.ExpenseRevision{}).
+			Where("expense_id = ? AND status = ?", expense.ID, models.StatusPending).
+			Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return errors.New("bu gider için zaten bekleyen bir değişiklik önerisi var")
+		}
+		if err := ensureBaseline(tx, &expense); err != nil {
+			return err
+		}
+		if err := saveRevision(tx, &rev); err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditRevision, rev.ID, models.AuditCreate, nil, &rev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// validateRevision gidere yazılmadan önce önerinin hâlâ geçerli olduğunu doğrular
+func validateRevision(db *gorm.DB, householdID uint, rev *models.ExpenseRevision) error {
+	v := &ValidationError{}
+	validateDescription(v, rev.Description)
+	if rev.Amount <= 0 {
+		v.add("amount", "tutar sıfırdan büyük olmalı")
+	}
+	if err := validateCategory(db, v, rev.CategoryID); err != nil {
+		return err
+	}
+	if err := validatePayer(db, v, householdID, rev.PaidBy); err != nil {
+		return err
+	}
+	return v.result()
+}
+
+// reviewPendingRevisions onay bekleyen giderin bekleyen sürümlerini (girilen gerçek tutar gibi)
+// giderin onayı ya da reddiyle birlikte işler
+func reviewPendingRevisions(tx *gorm.DB, expenseID uint, status models.ExpenseStatus, reviewerID uint, at time.Time) error {
+	return tx.Model(&models.ExpenseRevision{}).
+		Where("expense_id = ? AND status = ?", expenseID, models.StatusPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": reviewerID,
+			"reviewed_at": at,
+		}).Error
+}
+
+// findPendingRevision gidere ait bekleyen öneriyi okur
+func (s *ExpenseService) findPendingRevision(householdID, expenseID, revisionID uint) (*models.Expense, *models.ExpenseRevision, error) {
+	var expense models.Expense
+	if err := s.db.Scopes(inHousehold(householdID)).Preload("Splits").First(&expense, expenseID).Error; err != nil {
+		return nil, nil, err
+	}
+	var rev models.ExpenseRevision
+	if err := s.db.Where("expense_id = ?", expense.ID).First(&rev, revisionID).Error; err != nil {
+		return nil, nil, err
+	}
+	if rev.Status != models.StatusPending {
+		return nil, nil, errors.New("bu öneri zaten işlenmiş")
+	}
+	// Onay bekleyen giderin sürümleri giderin kendisiyle birlikte onaylanır
+	if expense.Status != models.StatusApproved {
+		return nil, nil, errors.New("bu sürüm giderin onayıyla birlikte işlenir")
+	}
+	return &expense, &rev, nil
+}
+
+// ApproveChange öneriyi gidere uygular. Öneriyi yapan kendi önerisini onaylayamaz.
+func (s *ExpenseService) ApproveChange(householdID, expenseID, revisionID, userID uint, isAdmin bool) error {
+	expense, rev, err := s.findPendingRevision(householdID, expenseID, revisionID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && rev.ProposedBy == userID {
+		return errors.New("kendi önerinizi onaylayamazsınız")
+	}
+	// Öneri yapıldıktan sonra kategori silinmiş ya da ödeyen haneden ayrılmış olabilir
+	if err := validateRevision(s.db, householdID, rev); err != nil {
+		return err
+	}
+
+	before := *expense
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, householdID, rev.ExpenseMonth, rev.ExpenseYear); err != nil {
+			return err
+		}
+		if err := ensurePeriodOpen(tx, householdID, expense.ExpenseMonth, expense.ExpenseYear); err != nil {
+			return err
+		}
+		err := tx.Model(expense).Updates(map[string]interface{}{
+			"category_id":   rev.CategoryID,
+			"description":   rev.Description,
+			"amount":        rev.Amount,
+			"paid_by":       rev.PaidBy,
+			"expense_date":  rev.ExpenseDate,
+			"expense_month": rev.ExpenseMonth,
+			"expense_year":  rev.ExpenseYear,
+			"is_shared":     rev.IsShared,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := resplit(tx, expense, revisionSpec(rev)); err != nil {
+			return err
+		}
+		err = tx.Model(rev).Updates(map[string]interface{}{
+			"status":      models.StatusApproved,
+			"reviewed_by": userID,
+			"reviewed_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var after models.Expense
+		if err := tx.Preload("Splits").First(&after, expense.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditUpdate, &before, &after)
+	})
+}
+
+// RejectChange öneriyi reddeder; öneriyi yapan da kendi önerisini geri çekebilir
+func (s *ExpenseService) RejectChange(householdID, expenseID, revisionID, userID uint) error {
+	_, rev, err := s.findPendingRevision(householdID, expenseID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	before := *rev
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(rev).Updates(map[string]interface{}{
+			"status":      models.StatusRejected,
+			"reviewed_by": userID,
+			"reviewed_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		after := before
+		after.Status = models.StatusRejected
+		after.ReviewedBy = &userID
+		after.ReviewedAt = &now
+		return recordAudit(tx, householdID, userID, auditRevision, rev.ID, models.AuditReject, &before, &after)
+	})
+}
+
+// History giderin tüm sürümlerini eskiden yeniye döner. Hiç düzenlenmemiş giderde
+// tek sürüm giderin kendisidir.
+func (s *ExpenseService) History(householdID, id uint) ([]models.ExpenseRevision, error) {
+	var expense models.Expense
+	if err := s.db.Scopes(inHousehold(householdID)).Preload("Splits").Preload("Creator").First(&expense, id).Error; err != nil {
+		return nil, err
+	}
+
+	var revisions []models.ExpenseRevision
+	err := s.db.Preload("Proposer").Preload("Reviewer").
+		Where("expense_id = ?", expense.ID).
+		Order("version ASC").
+		Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		base := revisionFrom(&expense)
+		base.Version = 1
+		base.ProposedBy = expense.CreatedBy
+		base.Proposer = expense.Creator
+		base.Status = expense.Status
+		base.CreatedAt = expense.CreatedAt
+		revisions = append(revisions, base)
+	}
+	return revisions, nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/caner/home-gider/internal/models"
+)
+
+func uintPtr(u uint) *uint { return &u }
+
+// İki üyeli hane: 1 ve 2. Her durumda en az bir üye onaylayabilmelidir.
+func TestApprovalError(t *testing.T) {
+	tests := []struct {
+		name     string
+		expense  models.Expense
+		approver uint
+		isAdmin  bool
+		allowed  bool
+	}{
+		{"giren onaylayamaz", models.Expense{CreatedBy: 1, PaidBy: 1}, 1, false, false},
+		{"diğer üye onaylar", models.Expense{CreatedBy: 1, PaidBy: 1}, 2, false, true},
+		{"ödeyen başkası olsa da giren onaylayamaz", models.Expense{CreatedBy: 1, PaidBy: 2}, 1, false, false},
+		{"ödeyen onaylayabilir", models.Expense{CreatedBy: 1, PaidBy: 2}, 2, false, true},
+		{"gerçek tutarı giren onaylayamaz", models.Expense{CreatedBy: 1, PaidBy: 1, ActualSetBy: uintPtr(2)}, 2, false, false},
+		{"şablonu açan gerçek tutarı onaylar", models.Expense{CreatedBy: 1, PaidBy: 1, ActualSetBy: uintPtr(2)}, 1, false, true},
+		{"tutarı açan kendisi girdiyse diğeri onaylar", models.Expense{CreatedBy: 1, PaidBy: 2, ActualSetBy: uintPtr(1)}, 2, false, true},
+		{"tutarı açan kendisi girdiyse onaylayamaz", models.Expense{CreatedBy: 1, PaidBy: 2, ActualSetBy: uintPtr(1)}, 1, false, false},
+		{"yönetici her zaman onaylar", models.Expense{CreatedBy: 1, PaidBy: 1, ActualSetBy: uintPtr(1)}, 1, true, true},
+	}
+	for _, tt := range tests {
+		err := approvalError(&tt.expense, tt.approver, tt.isAdmin)
+		if (err == nil) != tt.allowed {
+			t.Errorf("%s: hata = %v, onaylanabilir beklenen %v", tt.name, err, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+)
+
+// maxForecastMonths tahminde bakılabilecek en fazla ay sayısı
+const maxForecastMonths = 24
+
+// ForecastShare bir üyenin tahmini ödeyeceği ve payına düşen tutar
+type ForecastShare struct {
+	UserID      uint         `json:"user_id"`
+	DisplayName string       `json:"display_name"`
+	Paid        models.Money `json:"paid"`
+	Share       models.Money `json:"share"`
+}
+
+// ForecastOccurrence şablonun henüz oluşturulmamış bir tekrarı
+type ForecastOccurrence struct {
+	RecurringExpenseID uint                 `json:"recurring_expense_id"`
+	Description        string               `json:"description"`
+	CategoryID         uint                 `json:"category_id"`
+	Type               models.RecurringType `json:"type"`
+	Date               time.Time            `json:"date"`
+	Amount             models.Money         `json:"amount"`
+	IsEstimate         bool                 `json:"is_estimate"`
+	InstallmentNo      *int                 `json:"installment_no,omitempty"`
+	InstallmentTotal   *int                 `json:"installment_total,omitempty"`
+	PaidBy             uint                 `json:"paid_by"`
+	Splits             []ForecastShare      `json:"splits"`
+}
+
+// ForecastMonth bir dönemin tahmini giderleri
+type ForecastMonth struct {
+	Month       int                  `json:"month"`
+	Year        int                  `json:"year"`
+	From        time.Time            `json:"from"`
+	To          time.Time            `json:"to"`
+	Total       models.Money         `json:"total"`
+	Members     []ForecastShare      `json:"members"`
+	Occurrences []ForecastOccurrence `json:"occurrences"`
+}
+
+// ForecastInstallment taksit planının kalan taksitleri ve tahmini bitiş tarihi. Şablon süresiz
+// duraklatılmışsa bitiş tarihi bilinmez.
+type ForecastInstallment struct {
+	RecurringExpenseID    uint         `json:"recurring_expense_id"`
+	Description           string       `json:"description"`
+	InstallmentsRemaining int          `json:"installments_remaining"`
+	InstallmentCount      int          `json:"installment_count"`
+	Outstanding           models.Money `json:"outstanding"`
+	EndDate               *time.Time   `json:"end_date"`
+}
+
+// Forecast bugünden itibaren N dönem sonrasının bitimine kadar tahmini giderler
+type Forecast struct {
+	From         time.Time             `json:"from"`
+	To           time.Time             `json:"to"`
+	Total        models.Money          `json:"total"`
+	Members      []ForecastShare       `json:"members"`
+	Months       []ForecastMonth       `json:"months"`
+	Installments []ForecastInstallment `json:"installments"`
+}
+
+// forecastTally üye bazında ödenen ve pay toplamlarını tutar
+type forecastTally struct {
+	members []models.User
+	paid    map[uint]models.Money
+	share   map[uint]models.Money
+}
+
+func newForecastTally(members []models.User) *forecastTally {
+	return &forecastTally{members: members, paid: map[uint]models.Money{}, share: map[uint]models.Money{}}
+}
+
+func (t *forecastTally) add(o *ForecastOccurrence) {
+	t.paid[o.PaidBy] += o.Amount
+	for _, sp := range o.Splits {
+		t.share[sp.UserID] += sp.Share
+	}
+}
+
+func (t *forecastTally) result() []ForecastShare {
+	shares := make([]ForecastShare, 0, len(t.members))
+	for _, m := range t.members {
+		shares = append(shares, ForecastShare{
+			UserID:      m.ID,
+			DisplayName: m.DisplayName,
+			Paid:        t.paid[m.ID],
+			Share:       t.share[m.ID],
+		})
+	}
+	return shares
+}
+
+// projectedEnd taksit planının son taksitinin tahmini tarihi. Duraklatılmış ya da atlanan
+// dönemler planı öteler; süresiz duraklatmada nil döner.
+func projectedEnd(item *models.RecurringExpense, filter *occurrenceFilter, from time.Time) *time.Time {
+	if item.InstallmentsRemaining == nil || *item.InstallmentsRemaining <= 0 {
+		return nil
+	}
+	remaining := *item.InstallmentsRemaining
+	start := dateOnly(item.StartDate)
+	// Süresiz duraklatmada döngü sınırda biter
+	for k := 0; k < 5000; k++ {
+		date := occurrence(item, k)
+		if item.EndDate != nil && date.After(dateOnly(*item.EndDate)) {
+			return nil
+		}
+		if date.Before(from) || date.Before(start) {
+			continue
+		}
+		if state, _ := filter.check(date); state != occurrenceScheduled {
+			continue
+		}
+		remaining--
+		if remaining == 0 {
+			return &date
+		}
+	}
+	return nil
+}
+
+// Forecast aktif ve onaylı şablonlardan bugünden itibaren bu dönem ve sonraki months dönem için
+// oluşacak giderleri, taksit numaraları ve üye paylarıyla tahmin eder. Zaten oluşturulmuş
+// tekrarlar sayılmaz.
+func (s *RecurringService) Forecast(householdID uint, months int) (*Forecast, error) {
+	if months < 1 || months > maxForecastMonths {
+		months = 3
+	}
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
+	today := cal.today()
+	first, firstYear := cal.current()
+	last, lastYear := first, firstYear
+	for i := 0; i < months; i++ {
+		last, lastYear = nextPeriod(last, lastYear)
+	}
+	_, to := cal.bounds(last, lastYear)
+
+	members, err := householdMembers(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.RecurringExpense
+	err = s.db.Scopes(inHousehold(householdID)).
+		Where("is_active = ? AND status = ? AND payoff_expense_id IS NULL", true, models.StatusApproved).
+		Order("id ASC").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Forecast{From: today, To: to, Installments: []ForecastInstallment{}}
+	byPeriod := map[int][]ForecastOccurrence{}
+	for i := range items {
+		item := &items[i]
+		filter, err := loadOccurrenceFilter(s.db, item)
+		if err != nil {
+			return nil, err
+		}
+
+		from := resumeFrom(item)
+		if from.Before(today) {
+			from = today
+		}
+
+		// Zaten oluşturulmuş tekrarlar iki kez sayılmaz
+		var generated []time.Time
+		err = s.db.Model(&models.Expense{}).
+			Where("recurring_expense_id = ? AND occurrence_date >= ?", item.ID, from).
+			Pluck("occurrence_date", &generated).Error
+		if err != nil {
+			return nil, err
+		}
+		done := map[time.Time]bool{}
+		for _, d := range generated {
+			done[dateOnly(d)] = true
+		}
+
+		amount := item.Amount
+		isEstimate := item.Type == models.TypeVariable
+		if isEstimate {
+			if amount, err = estimateAmount(s.db, item); err != nil {
+				return nil, err
+			}
+		}
+
+		remaining := -1
+		if item.Type == models.TypeInstallment && item.InstallmentsRemaining != nil {
+			remaining = *item.InstallmentsRemaining
+			result.Installments = append(result.Installments, ForecastInstallment{
+				RecurringExpenseID:    item.ID,
+				Description:           item.Description,
+				InstallmentsRemaining: remaining,
+				InstallmentCount:      *item.InstallmentCount,
+				Outstanding:           outstandingPrincipal(item),
+				EndDate:               projectedEnd(item, filter, from),
+			})
+		}
+		next := paidInstallments(item) + 1
+
+		// Aynı tutarın payları her tekrar için yeniden hesaplanmaz
+		splitCache := map[models.Money][]ForecastShare{}
+		for _, date := range dueOccurrences(item, from, to) {
+			if remaining == 0 {
+				break
+			}
+			if done[date] {
+				continue
+			}
+			if state, _ := filter.check(date); state != occurrenceScheduled {
+				continue
+			}
+
+			o := ForecastOccurrence{
+				RecurringExpenseID: item.ID,
+				Description:        item.Description,
+				CategoryID:         item.CategoryID,
+				Type:               item.Type,
+				Date:               date,
+				Amount:             amount,
+				IsEstimate:         isEstimate,
+				PaidBy:             item.PaidBy,
+			}
+			if remaining > 0 {
+				no := next
+				o.InstallmentNo = &no
+				o.InstallmentTotal = item.InstallmentCount
+				o.Amount = installmentAmount(item, no)
+				next++
+				remaining--
+			}
+
+			splits, ok := splitCache[o.Amount]
+			if !ok {
+				_, parts, err := buildSplits(s.db, householdID, item.CreatedBy, o.Amount, item.IsShared, SplitSpec{Ratio: &item.SplitRatio})
+				if err != nil {
+					return nil, err
+				}
+				splits = make([]ForecastShare, 0, len(parts))
+				for _, p := range parts {
+					splits = append(splits, ForecastShare{UserID: p.UserID, Share: p.Amount})
+				}
+				splitCache[o.Amount] = splits
+			}
+			o.Splits = splits
+
+			m, y := cal.periodOf(date)
+			byPeriod[periodKey(m, y)] = append(byPeriod[periodKey(m, y)], o)
+		}
+	}
+
+	total := newForecastTally(members)
+	for m, y := first, firstYear; periodKey(m, y) <= periodKey(last, lastYear); m, y = nextPeriod(m, y) {
+		from, to := cal.bounds(m, y)
+		month := ForecastMonth{
+			Month:       m,
+			Year:        y,
+			From:        from,
+			To:          to,
+			Occurrences: []ForecastOccurrence{},
+		}
+		tally := newForecastTally(members)
+		for _, o := range byPeriod[periodKey(m, y)] {
+			month.Total += o.Amount
+			month.Occurrences = append(month.Occurrences, o)
+			tally.add(&o)
+			total.add(&o)
+		}
+		sort.SliceStable(month.Occurrences, func(i, j int) bool {
+			return month.Occurrences[i].Date.Before(month.Occurrences[j].Date)
+		})
+		month.Members = tally.result()
+		result.Total += month.Total
+		result.Months = append(result.Months, month)
+	}
+	result.Members = total.result()
+	return result, nil
+}
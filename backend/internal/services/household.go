@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HouseholdService struct {
+	db *gorm.DB
+}
+
+func NewHouseholdService(db *gorm.DB) *HouseholdService {
+	return &HouseholdService{db: db}
+}
+
+// inHousehold sorguyu verilen hanenin kayıtlarıyla sınırlar
+func inHousehold(householdID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("household_id = ?", householdID)
+	}
+}
+
+// forUpdate okunan satırları transaction sonuna kadar kilitler (SELECT ... FOR UPDATE)
+func forUpdate(db *gorm.DB) *gorm.DB {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// householdMembers hesaplaşmaya katılan üyeleri döner (admin kullanıcılar hariç)
+func householdMembers(db *gorm.DB, householdID uint) ([]models.User, error) {
+	var users []models.User
+	err := db.Joins("JOIN household_members ON household_members.user_id = users.id").
+		Where("household_members.household_id = ? AND users.is_admin = ?", householdID, false).
+		Order("users.id ASC").
+		Find(&users).Error
+	return users, err
+}
+
+// isHouseholdMember kullanıcının hanede üyeliği var mı?
+func isHouseholdMember(db *gorm.DB, householdID, userID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.HouseholdMember{}).
+		Where("household_id = ? AND user_id = ?", householdID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListForUser kullanıcının üye olduğu haneler
+func (s *HouseholdService) ListForUser(userID uint) ([]models.HouseholdMember, error) {
+	var memberships []models.HouseholdMember
+	err := s.db.Preload("Household").
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&memberships).Error
+	return memberships, err
+}
+
+// ListAll admin için tüm haneler
+func (s *HouseholdService) ListAll() ([]models.Household, error) {
+	var households []models.Household
+	err := s.db.Order("id ASC").Find(&households).Error
+	return households, err
+}
+
+func (s *HouseholdService) Members(householdID uint) ([]models.HouseholdMember, error) {
+	var members []models.HouseholdMember
+	err := s.db.Preload("User").
+		Where("household_id = ?", householdID).
+		Order("created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// Create yeni hane oluşturur, oluşturan kullanıcı hanenin yöneticisi olur
+func (s *HouseholdService) Create(name string, creatorID uint) (*models.Household, error) {
+	household := models.Household{Name: name}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&household).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.HouseholdMember{
+			HouseholdID: household.ID,
+			UserID:      creatorID,
+			Role:        models.RoleAdmin,
+		}).Error
+	})
+	return &household, err
+}
+
+func (s *HouseholdService) AddMember(householdID, userID uint, role models.HouseholdRole) error {
+	if role != models.RoleAdmin && role != models.RoleMember {
+		return errors.New("geçersiz rol")
+	}
+	var household models.Household
+	if err := s.db.First(&household, householdID).Error; err != nil {
+		return err
+	}
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return err
+	}
+	member, err := isHouseholdMember(s.db, householdID, userID)
+	if err != nil {
+		return err
+	}
+	if member {
+		return errors.New("kullanıcı zaten bu hanenin üyesi")
+	}
+	return s.db.Create(&models.HouseholdMember{
+		HouseholdID: householdID,
+		UserID:      userID,
+		Role:        role,
+	}).Error
+}
+
+// Settings hanenin dönem ayarlarını okur
+func (s *HouseholdService) Settings(householdID uint) (*models.Household, error) {
+	var household models.Household
+	err := s.db.First(&household, householdID).Error
+	return &household, err
+}
+
+// UpdateSettings dönem başlangıç gününü ve saat dilimini değiştirir. Açık dönemlerdeki giderler
+// yeni sınırlara göre yeniden dönemlendirilir; elle başka döneme yazılmış ya da kapatılmış
+// dönemlerdeki giderlere dokunulmaz. Ödemeler kaydedildikleri dönemde kalır: borç bakiyesi
+// dönemden döneme devrettiği için toplam bakiye değişmez, yalnızca dönem özetlerindeki
+// ödeme/gider dağılımı kayabilir.
+func (s *HouseholdService) UpdateSettings(householdID, userID uint, startDay int, timezone string) (*models.Household, error) {
+	var v ValidationError
+	if startDay < 1 || startDay > 28 {
+		v.add("period_start_day", "1 ile 28 arasında olmalıdır")
+	}
+	timezone = strings.TrimSpace(timezone)
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			v.add("timezone", "geçersiz saat dilimi")
+		}
+	}
+	if err := v.result(); err != nil {
+		return nil, err
+	}
+
+	var household models.Household
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(forUpdate).First(&household, householdID).Error; err != nil {
+			return err
+		}
+		before := household
+		oldCal := calendarFor(&household)
+		household.PeriodStartDay = startDay
+		household.Timezone = timezone
+		newCal := calendarFor(&household)
+
+		if err := tx.Model(&household).Updates(map[string]interface{}{
+			"period_start_day": startDay,
+			"timezone":         timezone,
+		}).Error; err != nil {
+			return err
+		}
+		if oldCal.startDay != newCal.startDay {
+			if err := repartition(tx, householdID, userID, oldCal, newCal); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, householdID, userID, auditHousehold, householdID, models.AuditUpdate, &before, &household)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &household, nil
+}
+
+// repartition giderlerin dönemini yeni takvime göre yeniden hesaplar. Dönemi eski takvimdeki
+// karşılığından farklı olan giderler elle dönemlendirilmiştir ve olduğu gibi kalır. Taşınan her
+// gider için audit kaydı yazılır.
+func repartition(tx *gorm.DB, householdID, actorID uint, oldCal, newCal *periodCalendar) error {
+	var locks []models.PeriodLock
+	if err := tx.Scopes(inHousehold(householdID)).Find(&locks).Error; err != nil {
+		return err
+	}
+	closed := map[int]bool{}
+	for _, l := range locks {
+		if l.IsLocked() {
+			closed[periodKey(l.Month, l.Year)] = true
+		}
+	}
+
+	var expenses []models.Expense
+	if err := tx.Scopes(inHousehold(householdID)).Find(&expenses).Error; err != nil {
+		return err
+	}
+	for _, e := range expenses {
+		date := dateOnly(e.ExpenseDate)
+		if m, y := oldCal.periodOf(date); m != e.ExpenseMonth || y != e.ExpenseYear {
+			continue
+		}
+		m, y := newCal.periodOf(date)
+		if m == e.ExpenseMonth && y == e.ExpenseYear {
+			continue
+		}
+		if closed[periodKey(e.ExpenseMonth, e.ExpenseYear)] || closed[periodKey(m, y)] {
+			continue
+		}
+		before := e
+		err := tx.Model(&models.Expense{}).Where("id = ?", e.ID).
+			Updates(map[string]interface{}{"expense_month": m, "expense_year": y}).Error
+		if err != nil {
+			return err
+		}
+		after := before
+		after.ExpenseMonth, after.ExpenseYear = m, y
+		if err := recordAudit(tx, householdID, actorID, auditExpense, e.ID, models.AuditUpdate, &before, &after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
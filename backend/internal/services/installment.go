@@ -0,0 +1,536 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxInstallments bir taksit planında izin verilen en fazla taksit sayısı
+const maxInstallments = 600
+
+// InstallmentLine ödeme planındaki bir taksit. Üretilmiş taksitlerde ExpenseID doludur.
+type InstallmentLine struct {
+	No            int                  `json:"no"`
+	Date          *time.Time           `json:"date"`
+	Payment       models.Money         `json:"payment"`
+	Interest      models.Money         `json:"interest"`
+	Principal     models.Money         `json:"principal"`
+	Balance       models.Money         `json:"balance"`
+	State         string               `json:"state"`
+	ExpenseID     *uint                `json:"expense_id,omitempty"`
+	ExpenseStatus models.ExpenseStatus `json:"expense_status,omitempty"`
+}
+
+// Taksit satırı durumları
+const (
+	lineGenerated = "generated"
+	linePayoff    = "payoff"
+	linePlanned   = "planned"
+)
+
+// InstallmentSchedule taksitli şablonun ödeme planı: üretilmiş taksitler ve kalanlar
+type InstallmentSchedule struct {
+	RecurringExpenseID uint              `json:"recurring_expense_id"`
+	Principal          models.Money      `json:"principal"`
+	InterestRate       float64           `json:"interest_rate"`
+	InstallmentCount   int               `json:"installment_count"`
+	Paid               models.Money      `json:"paid"`
+	Outstanding        models.Money      `json:"outstanding"`
+	TotalInterest      models.Money      `json:"total_interest"`
+	TotalPayable       models.Money      `json:"total_payable"`
+	Lines              []InstallmentLine `json:"lines"`
+}
+
+// amortize anaparayı n eşit taksitli (annüite) bir plana böler. rate dönem faizidir;
+// faizsiz planda kuruş farkları ilk taksitlere dağıtılır. Son taksit kalan borcu kapatır.
+func amortize(principal models.Money, rate float64, n int) []InstallmentLine {
+	if n <= 0 {
+		return nil
+	}
+	var payments []models.Money
+	if rate == 0 {
+		weights := make([]int64, n)
+		for i := range weights {
+			weights[i] = 1
+		}
+		var err error
+		if payments, err = principal.Allocate(weights); err != nil {
+			return nil
+		}
+	} else {
+		payment := models.Money(math.Round(float64(principal) * rate / (1 - math.Pow(1+rate, -float64(n)))))
+		payments = make([]models.Money, n)
+		for i := range payments {
+			payments[i] = payment
+		}
+	}
+
+	lines := make([]InstallmentLine, n)
+	balance := principal
+	for i := 0; i < n; i++ {
+		interest := models.Money(math.Round(float64(balance) * rate))
+		payment := payments[i]
+		part := payment - interest
+		if i == n-1 {
+			part = balance
+			payment = part + interest
+		}
+		balance -= part
+		lines[i] = InstallmentLine{
+			No:        i + 1,
+			Payment:   payment,
+			Interest:  interest,
+			Principal: part,
+			Balance:   balance,
+			State:     linePlanned,
+		}
+	}
+	return lines
+}
+
+// periodRate yıllık faiz oranını şablonun tekrar aralığına düşen dönem faizine çevirir
+func periodRate(item *models.RecurringExpense) float64 {
+	perYear := 12.0
+	switch item.Frequency {
+	case models.FrequencyWeekly:
+		perYear = 52
+	case models.FrequencyYearly:
+		perYear = 1
+	}
+	interval := item.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	return item.InterestRate / 100 / perYear * float64(interval)
+}
+
+// planPrincipal güncel planın anaparası. Anaparası olmayan eski şablonlarda taksit tutarı × sayı.
+func planPrincipal(item *models.RecurringExpense) models.Money {
+	if item.Principal != nil {
+		return *item.Principal
+	}
+	if item.InstallmentCount == nil {
+		return 0
+	}
+	return item.Amount * models.Money(*item.InstallmentCount)
+}
+
+// installmentLines güncel planın taksitlerini genel taksit numaralarıyla döner
+func installmentLines(item *models.RecurringExpense) []InstallmentLine {
+	if item.InstallmentCount == nil {
+		return nil
+	}
+	lines := amortize(planPrincipal(item), periodRate(item), *item.InstallmentCount-item.ScheduleOffset)
+	for i := range lines {
+		lines[i].No += item.ScheduleOffset
+	}
+	return lines
+}
+
+// installmentAmount verilen numaralı taksitin tutarı
+func installmentAmount(item *models.RecurringExpense, no int) models.Money {
+	lines := installmentLines(item)
+	if i := no - item.ScheduleOffset - 1; i >= 0 && i < len(lines) {
+		return lines[i].Payment
+	}
+	return item.Amount
+}
+
+// paidInstallments şimdiye kadar üretilmiş taksit sayısı
+func paidInstallments(item *models.RecurringExpense) int {
+	if item.InstallmentCount == nil || item.InstallmentsRemaining == nil {
+		return 0
+	}
+	return *item.InstallmentCount - *item.InstallmentsRemaining
+}
+
+// outstandingPrincipal üretilmiş taksitlerden sonra kalan anapara borcu
+func outstandingPrincipal(item *models.RecurringExpense) models.Money {
+	k := paidInstallments(item) - item.ScheduleOffset
+	if k <= 0 {
+		return planPrincipal(item)
+	}
+	lines := installmentLines(item)
+	if k > len(lines) {
+		return 0
+	}
+	return lines[k-1].Balance
+}
+
+// planInstallments yeni taksitli şablonun planını kurar. Anapara verilmezse taksit tutarı ×
+// sayı anapara kabul edilir; toplam tutar verildiyse plandaki toplamla uyuşmalıdır.
+func planInstallments(item *models.RecurringExpense) error {
+	v := &ValidationError{}
+	if item.InstallmentCount == nil || *item.InstallmentCount <= 0 {
+		return errors.New("taksit sayısı belirtilmelidir")
+	}
+	if *item.InstallmentCount > maxInstallments {
+		v.add("installment_count", "taksit sayısı çok büyük")
+	}
+	if item.InterestRate < 0 || item.InterestRate > 100 {
+		v.add("interest_rate", "faiz oranı 0 ile 100 arasında olmalı")
+	}
+	if item.Principal == nil {
+		switch {
+		case item.InterestRate > 0:
+			v.add("principal", "faizli taksitte anapara belirtilmelidir")
+		case item.Amount > 0:
+			principal := item.Amount * models.Money(*item.InstallmentCount)
+			item.Principal = &principal
+		case item.TotalAmount != nil:
+			principal := *item.TotalAmount
+			item.Principal = &principal
+		default:
+			v.add("amount", "taksit tutarı ya da anapara belirtilmelidir")
+		}
+	}
+	if item.Principal != nil && *item.Principal <= 0 {
+		v.add("principal", "anapara sıfırdan büyük olmalı")
+	}
+	if err := v.result(); err != nil {
+		return err
+	}
+
+	item.ScheduleOffset = 0
+	lines := installmentLines(item)
+	var total models.Money
+	for _, l := range lines {
+		total += l.Payment
+	}
+	if item.TotalAmount != nil && *item.TotalAmount != total {
+		return &ValidationError{Fields: map[string]string{
+			"total_amount": "toplam tutar taksit planıyla uyuşmuyor (" + total.String() + ")",
+		}}
+	}
+	item.Amount = lines[0].Payment
+	item.TotalAmount = &total
+	remaining := *item.InstallmentCount
+	item.InstallmentsRemaining = &remaining
+	return nil
+}
+
+// findOpenInstallment değiştirilebilecek durumdaki taksitli şablonu okur
+func (s *RecurringService) findOpenInstallment(householdID, id, userID uint) (*models.RecurringExpense, error) {
+	var item models.RecurringExpense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	if item.CreatedBy != userID {
+		return nil, errors.New("sadece kendi oluşturduğunuz şablonları değiştirebilirsiniz")
+	}
+	if item.Type != models.TypeInstallment {
+		return nil, errors.New("bu şablon taksitli değil")
+	}
+	if item.Status != models.StatusApproved {
+		return nil, errors.New("taksit planı henüz onaylanmamış")
+	}
+	if item.PayoffExpenseID != nil {
+		return nil, errors.New("taksit planı erken kapatılmış ya da erken kapama onay bekliyor")
+	}
+	if !item.IsActive || item.InstallmentsRemaining == nil || *item.InstallmentsRemaining <= 0 {
+		return nil, errors.New("taksit planı tamamlanmış")
+	}
+	return &item, nil
+}
+
+// Schedule taksitli şablonun ödeme planını döner. Üretilmiş taksitler gerçek giderlerden,
+// kalanlar güncel plandan gelir. Yeniden yapılandırmadan önceki taksitlerde yalnızca ödenen
+// tutar bilinir.
+func (s *RecurringService) Schedule(householdID, id uint) (*InstallmentSchedule, error) {
+	var item models.RecurringExpense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	if item.Type != models.TypeInstallment || item.InstallmentCount == nil {
+		return nil, errors.New("bu şablon taksitli değil")
+	}
+
+	var expenses []models.Expense
+	err := s.db.Where("recurring_expense_id = ? AND is_installment = ?", item.ID, true).
+		Order("installment_no ASC, id ASC").
+		Find(&expenses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	plan := installmentLines(&item)
+	result := &InstallmentSchedule{
+		RecurringExpenseID: item.ID,
+		Principal:          planPrincipal(&item),
+		InterestRate:       item.InterestRate,
+		InstallmentCount:   *item.InstallmentCount,
+		Lines:              []InstallmentLine{},
+	}
+	for _, l := range plan {
+		result.TotalInterest += l.Interest
+	}
+
+	for _, e := range expenses {
+		no := 0
+		if e.InstallmentNo != nil {
+			no = *e.InstallmentNo
+		}
+		date := e.ExpenseDate
+		if e.OccurrenceDate != nil {
+			date = *e.OccurrenceDate
+		}
+		id := e.ID
+		line := InstallmentLine{
+			No:            no,
+			Date:          &date,
+			Payment:       e.Amount,
+			State:         lineGenerated,
+			ExpenseID:     &id,
+			ExpenseStatus: e.Status,
+		}
+		if item.PayoffExpenseID != nil && *item.PayoffExpenseID == e.ID {
+			line.State = linePayoff
+			line.Principal = e.Amount
+		} else if i := no - item.ScheduleOffset - 1; i >= 0 && i < len(plan) {
+			line.Interest = plan[i].Interest
+			line.Principal = plan[i].Principal
+			line.Balance = plan[i].Balance
+		}
+		if e.Status != models.StatusRejected {
+			result.Paid += e.Amount
+		}
+		result.Lines = append(result.Lines, line)
+	}
+
+	// Kalan taksitler ileriki vade tarihleriyle
+	result.TotalPayable = result.Paid
+	if item.IsActive && item.PayoffExpenseID == nil && item.InstallmentsRemaining != nil {
+		result.Outstanding = outstandingPrincipal(&item)
+		remaining := *item.InstallmentsRemaining
+		dates := upcomingOccurrences(&item, resumeFrom(&item), remaining)
+		for j := 0; j < remaining; j++ {
+			i := paidInstallments(&item) - item.ScheduleOffset + j
+			if i < 0 || i >= len(plan) {
+				continue
+			}
+			line := plan[i]
+			if j < len(dates) {
+				date := dates[j]
+				line.Date = &date
+			}
+			result.TotalPayable += line.Payment
+			result.Lines = append(result.Lines, line)
+		}
+	}
+	return result, nil
+}
+
+// Payoff kalan taksitleri tek bir giderle kapatır. Gider kalan anapara kadardır (ileriki
+// dönemlerin faizi ödenmez) ve ortak giderlerde diğer üyenin onayını bekler; plan gider
+// onaylanınca kapanır, reddedilirse devam eder.
+func (s *RecurringService) Payoff(householdID, id, userID uint) (*models.Expense, error) {
+	item, err := s.findOpenInstallment(householdID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
+	today := cal.today()
+	month, year := cal.periodOf(today)
+
+	var expense models.Expense
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, householdID, month, year); err != nil {
+			return err
+		}
+		// Aynı anda taksit üretilmesin diye şablon kilitlenir
+		if err := tx.Scopes(forUpdate).First(item, item.ID).Error; err != nil {
+			return err
+		}
+		if !item.IsActive || item.InstallmentsRemaining == nil || *item.InstallmentsRemaining <= 0 {
+			return errors.New("taksit planı tamamlanmış")
+		}
+		balance := outstandingPrincipal(item)
+		if balance <= 0 {
+			return errors.New("kalan borç yok")
+		}
+
+		no := paidInstallments(item) + 1
+		expense = models.Expense{
+			HouseholdID:        item.HouseholdID,
+			CreatedBy:          userID,
+			PaidBy:             item.PaidBy,
+			CategoryID:         item.CategoryID,
+			Description:        item.Description + " (erken kapama)",
+			Amount:             balance,
+			ExpenseDate:        today,
+			ExpenseMonth:       month,
+			ExpenseYear:        year,
+			IsShared:           item.IsShared,
+			IsInstallment:      true,
+			InstallmentNo:      &no,
+			InstallmentTotal:   item.InstallmentCount,
+			RecurringExpenseID: &item.ID,
+			Status:             models.StatusPending,
+		}
+		if !item.IsShared {
+			expense.Status = models.StatusApproved
+		}
+		mode, splits, err := buildSplits(tx, item.HouseholdID, item.CreatedBy, balance, item.IsShared, SplitSpec{Ratio: &item.SplitRatio})
+		if err != nil {
+			return err
+		}
+		expense.SplitMode = mode
+		expense.Splits = splits
+		if err := tx.Create(&expense).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, householdID, userID, auditExpense, expense.ID, models.AuditCreate, nil, &expense); err != nil {
+			return err
+		}
+
+		// Erken kapama onaylanana kadar yeni taksit üretilmez; plan onayla birlikte kapanır
+		before := *item
+		if err := tx.Model(item).Update("payoff_expense_id", expense.ID).Error; err != nil {
+			return err
+		}
+		after := before
+		after.PayoffExpenseID = &expense.ID
+		if err := recordAudit(tx, householdID, userID, auditRecurring, item.ID, models.AuditUpdate, &before, &after); err != nil {
+			return err
+		}
+		if expense.Status == models.StatusApproved {
+			item.PayoffExpenseID = &expense.ID
+			return closeInstallmentPlan(tx, item, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// restructurePlan kalan borcu yeni taksit sayısına ve faiz oranına göre yeniden planlar ve
+// şablona yazılacak sütunları döner. item yeni planla güncellenir.
+func restructurePlan(db *gorm.DB, item *models.RecurringExpense, remaining int, interestRate float64) (map[string]interface{}, error) {
+	paid := paidInstallments(item)
+	balance := outstandingPrincipal(item)
+	var paidSum models.Money
+	err := db.Model(&models.Expense{}).
+		Select("COALESCE(SUM(amount), 0)::BIGINT").
+		Where("recurring_expense_id = ? AND is_installment = ? AND status <> ?", item.ID, true, models.StatusRejected).
+		Scan(&paidSum).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lines := reschedule(item, remaining, interestRate)
+	total := paidSum
+	for _, l := range lines {
+		total += l.Payment
+	}
+	item.TotalAmount = &total
+
+	return map[string]interface{}{
+		"principal":              balance,
+		"interest_rate":          interestRate,
+		"schedule_offset":        paid,
+		"installment_count":      *item.InstallmentCount,
+		"installments_remaining": remaining,
+		"amount":                 item.Amount,
+		"total_amount":           total,
+	}, nil
+}
+
+// reschedule üretilmiş taksitleri olduğu gibi bırakıp kalan anaparayı remaining taksitlik yeni
+// bir plana böler. Yeni taksitler genel numaralamada kaldığı yerden devam eder.
+func reschedule(item *models.RecurringExpense, remaining int, interestRate float64) []InstallmentLine {
+	paid := paidInstallments(item)
+	balance := outstandingPrincipal(item)
+	count := paid + remaining
+	item.Principal = &balance
+	item.ScheduleOffset = paid
+	item.InstallmentCount = &count
+	item.InstallmentsRemaining = &remaining
+	item.InterestRate = interestRate
+	lines := installmentLines(item)
+	item.Amount = lines[0].Payment
+	return lines
+}
+
+// Restructure kalan borcu yeni taksit sayısına (ve istenirse yeni faiz oranına) göre yeniden
+// planlayan bir sürüm önerir. Plan diğer üye onaylayınca, o anki kalan borç üzerinden uygulanır.
+func (s *RecurringService) Restructure(householdID, id, userID uint, remaining int, interestRate *float64) (*models.RecurringRevision, error) {
+	v := &ValidationError{}
+	if remaining < 1 || remaining > maxInstallments {
+		v.add("installments_remaining", "kalan taksit sayısı 1 ile 600 arasında olmalı")
+	}
+	if interestRate != nil && (*interestRate < 0 || *interestRate > 100) {
+		v.add("interest_rate", "faiz oranı 0 ile 100 arasında olmalı")
+	}
+	if err := v.result(); err != nil {
+		return nil, err
+	}
+	item, err := s.findOpenInstallment(householdID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := item.InterestRate
+	if interestRate != nil {
+		rate = *interestRate
+	}
+	// Önerideki tutarlar bugünkü kalan borca göre bir ön izlemedir
+	preview := *item
+	if _, err := restructurePlan(s.db, &preview, remaining, rate); err != nil {
+		return nil, err
+	}
+	rev := recurringRevisionFrom(item)
+	rev.Amount = preview.Amount
+	rev.TotalAmount = preview.TotalAmount
+	rev.Principal = preview.Principal
+	rev.InterestRate = &rate
+	rev.InstallmentsRemaining = &remaining
+	return s.saveProposal(item, userID, &rev)
+}
+
+// closeInstallmentPlan erken kapama gideri onaylanınca taksit planını kapatır
+func closeInstallmentPlan(tx *gorm.DB, item *models.RecurringExpense, actorID uint) error {
+	before := *item
+	err := tx.Model(item).Updates(map[string]interface{}{
+		"installments_remaining": 0,
+		"is_active":              false,
+	}).Error
+	if err != nil {
+		return err
+	}
+	after := before
+	zero := 0
+	after.InstallmentsRemaining = &zero
+	after.IsActive = false
+	return recordAudit(tx, item.HouseholdID, actorID, auditRecurring, item.ID, models.AuditUpdate, &before, &after)
+}
+
+// settlePayoff erken kapama gideri onaylanırsa planı kapatır, reddedilirse plan kaldığı yerden
+// devam eder. Gider bir erken kapama değilse hiçbir şey yapmaz.
+func settlePayoff(tx *gorm.DB, expense *models.Expense, approved bool, actorID uint) error {
+	var item models.RecurringExpense
+	err := tx.Scopes(forUpdate).
+		Where("payoff_expense_id = ? AND is_active = ?", expense.ID, true).
+		Limit(1).Find(&item).Error
+	if err != nil || item.ID == 0 {
+		return err
+	}
+	if approved {
+		return closeInstallmentPlan(tx, &item, actorID)
+	}
+	before := item
+	if err := tx.Model(&item).Update("payoff_expense_id", nil).Error; err != nil {
+		return err
+	}
+	after := before
+	after.PayoffExpenseID = nil
+	return recordAudit(tx, item.HouseholdID, actorID, auditRecurring, item.ID, models.AuditUpdate, &before, &after)
+}
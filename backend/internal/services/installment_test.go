@@ -0,0 +1,130 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/caner/home-gider/internal/models"
+)
+
+func moneyPtr(m models.Money) *models.Money { return &m }
+
+// checkPlan planın iç tutarlılığını doğrular: her taksit anapara + faizdir, ödemelerin
+// toplamı anapara ile faizlerin toplamına eşittir ve son taksitte borç kapanır
+func checkPlan(t *testing.T, name string, principal models.Money, lines []InstallmentLine) {
+	t.Helper()
+	var payments, interest, parts models.Money
+	for _, l := range lines {
+		if l.Payment != l.Principal+l.Interest {
+			t.Errorf("%s: %d. taksit %s ≠ %s + %s", name, l.No, l.Payment, l.Principal, l.Interest)
+		}
+		payments += l.Payment
+		interest += l.Interest
+		parts += l.Principal
+	}
+	if parts != principal {
+		t.Errorf("%s: anapara parçalarının toplamı %s, beklenen %s", name, parts, principal)
+	}
+	if payments != principal+interest {
+		t.Errorf("%s: ödemeler %s, anapara + faiz %s", name, payments, principal+interest)
+	}
+	if last := lines[len(lines)-1]; last.Balance != 0 {
+		t.Errorf("%s: son taksitten sonra kalan borç %s", name, last.Balance)
+	}
+}
+
+func TestAmortize(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal models.Money
+		rate      float64
+		n         int
+	}{
+		{"yıllık %12, 12 ay", 1000000, 0.01, 12},
+		{"yüksek faiz", 2500000, 0.045, 36},
+		{"tek taksit", 99999, 0.02, 1},
+		{"faizsiz", 100000, 0, 3},
+		{"küçük tutar çok taksit", 100, 0.01, 7},
+	}
+	for _, tt := range tests {
+		lines := amortize(tt.principal, tt.rate, tt.n)
+		if len(lines) != tt.n {
+			t.Fatalf("%s: %d taksit, beklenen %d", tt.name, len(lines), tt.n)
+		}
+		checkPlan(t, tt.name, tt.principal, lines)
+	}
+}
+
+// Eşit taksitlerin yuvarlama farkını son taksit üstlenir
+func TestAmortizeLastInstallmentAbsorbsRounding(t *testing.T) {
+	lines := amortize(1000000, 0.01, 12)
+	for _, l := range lines[:11] {
+		if l.Payment != 88849 {
+			t.Errorf("%d. taksit %s, beklenen 888.49", l.No, l.Payment)
+		}
+	}
+	if last := lines[11]; last.Payment != 88847 {
+		t.Errorf("son taksit %s, beklenen 888.47", last.Payment)
+	}
+}
+
+func TestAmortizeZeroRate(t *testing.T) {
+	lines := amortize(100000, 0, 3)
+	want := []models.Money{33334, 33333, 33333}
+	for i, l := range lines {
+		if l.Payment != want[i] || l.Interest != 0 {
+			t.Errorf("%d. taksit %s (faiz %s), beklenen %s", l.No, l.Payment, l.Interest, want[i])
+		}
+	}
+	if amortize(100000, 0, 0) != nil {
+		t.Error("taksitsiz plan boş olmalı")
+	}
+}
+
+// Yeniden yapılandırma üretilmiş taksitlere dokunmaz: yeni plan kalan borçtan başlar,
+// numaralandırma kaldığı yerden devam eder ve ödenen kısım plana geri eklenmez
+func TestReschedule(t *testing.T) {
+	count, remaining := 12, 8
+	item := &models.RecurringExpense{
+		Type:                  models.TypeInstallment,
+		Frequency:             models.FrequencyMonthly,
+		Interval:              1,
+		InterestRate:          12,
+		Principal:             moneyPtr(1000000),
+		InstallmentCount:      &count,
+		InstallmentsRemaining: &remaining,
+	}
+	original := installmentLines(item)
+	balance := outstandingPrincipal(item)
+	if balance != original[3].Balance {
+		t.Fatalf("4 taksitten sonra kalan borç %s, beklenen %s", balance, original[3].Balance)
+	}
+
+	lines := reschedule(item, 6, 24)
+	if len(lines) != 6 || lines[0].No != 5 || lines[5].No != 10 {
+		t.Fatalf("yeni plan %d taksit, %d–%d numaralı", len(lines), lines[0].No, lines[len(lines)-1].No)
+	}
+	if item.ScheduleOffset != 4 || *item.InstallmentCount != 10 || *item.InstallmentsRemaining != 6 {
+		t.Errorf("offset %d, sayı %d, kalan %d", item.ScheduleOffset, *item.InstallmentCount, *item.InstallmentsRemaining)
+	}
+	if *item.Principal != balance || item.Amount != lines[0].Payment {
+		t.Errorf("anapara %s (beklenen %s), taksit %s", *item.Principal, balance, item.Amount)
+	}
+	checkPlan(t, "yeniden yapılandırma", balance, lines)
+
+	var paid models.Money
+	for _, l := range original[:4] {
+		paid += l.Principal
+	}
+	if paid+balance != 1000000 {
+		t.Errorf("ödenen anapara %s + kalan %s ≠ ilk anapara", paid, balance)
+	}
+
+	// Yeni plandan iki taksit daha üretildikten sonra
+	*item.InstallmentsRemaining = 4
+	if got := outstandingPrincipal(item); got != lines[1].Balance {
+		t.Errorf("kalan borç %s, beklenen %s", got, lines[1].Balance)
+	}
+	if got := installmentAmount(item, 7); got != lines[2].Payment {
+		t.Errorf("7. taksit %s, beklenen %s", got, lines[2].Payment)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultInviteTTL = 7 * 24 * time.Hour
+	maxInviteTTL     = 30 * 24 * time.Hour
+)
+
+var errInvalidInvite = errors.New("davet kodu geçersiz veya süresi dolmuş")
+
+type InviteService struct {
+	db *gorm.DB
+}
+
+func NewInviteService(db *gorm.DB) *InviteService {
+	return &InviteService{db: db}
+}
+
+// hashInviteToken davet kodunun veritabanında saklanan özeti
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newInviteToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Create hane için yeni davet oluşturur. Dönen kod sadece bu noktada açık olarak bilinir.
+func (s *InviteService) Create(householdID, creatorID uint, ttl time.Duration) (*models.Invite, string, error) {
+	if ttl <= 0 {
+		ttl = defaultInviteTTL
+	}
+	if ttl > maxInviteTTL {
+		return nil, "", errors.New("davet süresi en fazla 30 gün olabilir")
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	invite := models.Invite{
+		HouseholdID: householdID,
+		CreatedBy:   creatorID,
+		TokenHash:   hashInviteToken(token),
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := s.db.Create(&invite).Error; err != nil {
+		return nil, "", err
+	}
+	return &invite, token, nil
+}
+
+// ListOutstanding kullanılmamış, iptal edilmemiş ve süresi dolmamış davetler
+func (s *InviteService) ListOutstanding(householdID uint) ([]models.Invite, error) {
+	var invites []models.Invite
+	err := s.db.Scopes(inHousehold(householdID)).Preload("Creator").
+		Where("used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", time.Now()).
+		Order("created_at DESC").
+		Find(&invites).Error
+	return invites, err
+}
+
+func (s *InviteService) Revoke(householdID, id uint) error {
+	var invite models.Invite
+	if err := s.db.Scopes(inHousehold(householdID)).First(&invite, id).Error; err != nil {
+		return err
+	}
+	if invite.UsedAt != nil {
+		return errors.New("kullanılmış davet iptal edilemez")
+	}
+	if invite.RevokedAt != nil {
+		return errors.New("davet zaten iptal edilmiş")
+	}
+	return s.db.Model(&invite).Update("revoked_at", time.Now()).Error
+}
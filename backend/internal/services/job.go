@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrJobRunning iş başka bir replikada zaten çalışıyorsa döner
+var ErrJobRunning = errors.New("iş başka bir yerde çalışıyor")
+
+// İş adları ve tetikleyiciler
+const (
+	JobRecurring  = "recurring"
+	TriggerCron   = "cron"
+	TriggerManual = "manual"
+)
+
+type JobService struct {
+	db *gorm.DB
+}
+
+func NewJobService(db *gorm.DB) *JobService {
+	return &JobService{db: db}
+}
+
+// Run işi çalıştırır ve başlangıç, bitiş, sonuç ve oluşturulan kayıt sayısını saklar.
+// Çalışma kaydı yazılamazsa iş yine de çalıştırılır; kayıt hatası sadece loglanır.
+func (s *JobService) Run(job, trigger string, triggeredBy uint, fn func() (int, error)) (*models.JobRun, error) {
+	run := models.JobRun{
+		Job:       job,
+		Trigger:   trigger,
+		Status:    models.JobRunning,
+		StartedAt: time.Now(),
+	}
+	if triggeredBy != 0 {
+		run.TriggeredBy = &triggeredBy
+	}
+	if err := s.db.Create(&run).Error; err != nil {
+		log.Printf("İş kaydı oluşturulamadı (%s): %v", job, err)
+	}
+
+	created, err := fn()
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.ItemsCreated = created
+	switch {
+	case errors.Is(err, ErrJobRunning):
+		run.Status = models.JobSkipped
+		run.Error = err.Error()
+	case err != nil:
+		run.Status = models.JobFailed
+		run.Error = err.Error()
+	default:
+		run.Status = models.JobSucceeded
+	}
+
+	if run.ID != 0 {
+		if saveErr := s.db.Save(&run).Error; saveErr != nil {
+			log.Printf("İş kaydı güncellenemedi (%s): %v", job, saveErr)
+		}
+	}
+	return &run, err
+}
+
+// List işin son çalışmalarını en yeniden eskiye döner; job boşsa tüm işler
+func (s *JobService) List(job string, limit int) ([]models.JobRun, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	query := s.db.Order("started_at DESC").Limit(limit)
+	if job != "" {
+		query = query.Where("job = ?", job)
+	}
+	var runs []models.JobRun
+	err := query.Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"math"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+// allPeriods ledger toplamlarında tüm ayları kapsayan üst sınır
+const allPeriods = math.MaxInt32
+
+// ledgerEntry bir üyenin belirli bir ana kadar biriken hareketleri
+type ledgerEntry struct {
+	Paid     models.Money
+	Share    models.Money
+	Made     models.Money
+	Received models.Money
+}
+
+// net pozitifse üye alacaklı, negatifse borçlu
+func (e ledgerEntry) net() models.Money {
+	return e.Paid - e.Share + e.Made - e.Received
+}
+
+type userTotal struct {
+	UserID uint
+	Total  models.Money
+}
+
+// ledgerTotals verilen dönemden (periodKey) önceki tüm onaylı gider ve ödemeleri üye bazında toplar
+func (s *SettlementService) ledgerTotals(householdID uint, beforeKey int) (map[uint]*ledgerEntry, error) {
+	entries := make(map[uint]*ledgerEntry)
+	entry := func(userID uint) *ledgerEntry {
+		if _, ok := entries[userID]; !ok {
+			entries[userID] = &ledgerEntry{}
+		}
+		return entries[userID]
+	}
+
+	var paid, shares, made, received []userTotal
+	err := s.db.Model(&models.Expense{}).
+		Select("paid_by AS user_id, SUM(amount)::BIGINT AS total").
+		Where("household_id = ? AND status = ? AND expense_year * 12 + expense_month < ?",
+			householdID, models.StatusApproved, beforeKey).
+		Group("paid_by").
+		Scan(&paid).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Model(&models.ExpenseSplit{}).
+		Select("expense_splits.user_id AS user_id, SUM(expense_splits.amount)::BIGINT AS total").
+		Joins("JOIN expenses ON expenses.id = expense_splits.expense_id").
+		Where("expenses.household_id = ? AND expenses.status = ? AND expenses.expense_year * 12 + expenses.expense_month < ?",
+			householdID, models.StatusApproved, beforeKey).
+		Group("expense_splits.user_id").
+		Scan(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+
+	payments := s.db.Model(&models.Payment{}).
+		Where("household_id = ? AND status = ? AND year * 12 + month < ?",
+			householdID, models.StatusApproved, beforeKey).
+		Session(&gorm.Session{})
+	err = payments.Select("payer_id AS user_id, SUM(amount)::BIGINT AS total").
+		Group("payer_id").
+		Scan(&made).Error
+	if err != nil {
+		return nil, err
+	}
+	err = payments.Select("payee_id AS user_id, SUM(amount)::BIGINT AS total").
+		Group("payee_id").
+		Scan(&received).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range paid {
+		entry(t.UserID).Paid = t.Total
+	}
+	for _, t := range shares {
+		entry(t.UserID).Share = t.Total
+	}
+	for _, t := range made {
+		entry(t.UserID).Made = t.Total
+	}
+	for _, t := range received {
+		entry(t.UserID).Received = t.Total
+	}
+	return entries, nil
+}
+
+// BalanceSummary tüm aylar boyunca biriken net durum
+type BalanceSummary struct {
+	Members   []UserSummary `json:"members"`
+	Transfers []Transfer    `json:"transfers"`
+}
+
+// GetBalance her üyenin başlangıçtan bugüne kümülatif net bakiyesini ve kapatıcı transferleri döner
+func (s *SettlementService) GetBalance(householdID uint) (*BalanceSummary, error) {
+	users, err := householdMembers(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.ledgerTotals(householdID, allPeriods)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]UserSummary, 0, len(users))
+	for _, u := range users {
+		summary := UserSummary{UserID: u.ID, DisplayName: u.DisplayName}
+		if t, ok := totals[u.ID]; ok {
+			summary.TotalPaid = t.Paid
+			summary.TotalShare = t.Share
+			summary.Balance = t.Paid - t.Share
+			summary.PaymentsMade = t.Made
+			summary.PaymentsReceived = t.Received
+			summary.NetBalance = t.net()
+			summary.ClosingBalance = t.net()
+		}
+		members = append(members, summary)
+	}
+
+	return &BalanceSummary{
+		Members:   members,
+		Transfers: simplifyDebts(members, func(u UserSummary) models.Money { return u.NetBalance }),
+	}, nil
+}
+
+// AddBalancePayment belirli bir aya değil, kümülatif bakiyeye karşı ödeme kaydeder.
+// Ödeme içinde bulunulan aya yazılır, böylece sonraki ayların açılış bakiyesine yansır.
+func (s *SettlementService) AddBalancePayment(householdID, payerID, payeeID uint, amount models.Money) error {
+	if payerID == payeeID {
+		return errors.New("kendinize ödeme yapamazsınız")
+	}
+
+	balance, err := s.GetBalance(householdID)
+	if err != nil {
+		return err
+	}
+	transfer := findTransfer(balance.Transfers, payerID, payeeID)
+	if transfer == nil {
+		return errors.New("bu kişiye borcunuz yok")
+	}
+	if err := s.checkPaymentAmount(householdID, 0, 0, payerID, payeeID, amount, transfer.Amount); err != nil {
+		return err
+	}
+
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return err
+	}
+	month, year := cal.current()
+	payment := models.Payment{
+		HouseholdID: householdID,
+		Month:       month,
+		Year:        year,
+		PayerID:     payerID,
+		PayeeID:     payeeID,
+		Amount:      amount,
+		Status:      models.StatusPending,
+	}
+	return s.createPayment(&payment)
+}
+
+// findTransfer belirli borçlu → alacaklı çifti için transferi bulur
+func findTransfer(transfers []Transfer, fromID, toID uint) *Transfer {
+	for i := range transfers {
+		if transfers[i].FromID == fromID && transfers[i].ToID == toID {
+			return &transfers[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+// ValidationError alan bazlı doğrulama hataları; API bunları 422 ile döner
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %s", k, e.Fields[k]))
+	}
+	return "geçersiz alanlar — " + strings.Join(parts, ", ")
+}
+
+func (e *ValidationError) add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = map[string]string{}
+	}
+	if _, ok := e.Fields[field]; !ok {
+		e.Fields[field] = message
+	}
+}
+
+// result hata yoksa nil döner
+func (e *ValidationError) result() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func validateCategory(db *gorm.DB, v *ValidationError, categoryID uint) error {
+	var count int64
+	if err := db.Model(&models.Category{}).Where("id = ?", categoryID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		v.add("category_id", "kategori bulunamadı")
+	}
+	return nil
+}
+
+func validatePayer(db *gorm.DB, v *ValidationError, householdID, payerID uint) error {
+	member, err := isHouseholdMember(db, householdID, payerID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		v.add("paid_by", "ödeyen kişi bu hanenin üyesi değil")
+	}
+	return nil
+}
+
+func validateDescription(v *ValidationError, description string) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		v.add("description", "açıklama boş olamaz")
+	} else if len(description) > 255 {
+		v.add("description", "açıklama en fazla 255 karakter olabilir")
+	}
+}
+
+func validateRatio(v *ValidationError, field string, ratio float64) {
+	if ratio < 0 || ratio > 100 {
+		v.add(field, "0 ile 100 arasında olmalı")
+	}
+}
+
+// ExpenseChanges gider için düzenleme ya da değişiklik önerisi. Sadece burada tanımlı
+// alanlar değiştirilebilir; boş (nil) alanlar aynen kalır. Bölüşüm alanlarından biri
+// verilirse bölüşüm tanımının tamamı yenisiyle değişir.
+type ExpenseChanges struct {
+	CategoryID  *uint             `json:"category_id"`
+	Description *string           `json:"description"`
+	Amount      *models.Money     `json:"amount"`
+	PaidBy      *uint             `json:"paid_by"`
+	Date        *string           `json:"date"`
+	Month       *int              `json:"month"`
+	Year        *int              `json:"year"`
+	IsShared    *bool             `json:"is_shared"`
+	SplitMode   *models.SplitMode `json:"split_mode"`
+	Splits      []SplitInput      `json:"splits"`
+	SplitRatio  *float64          `json:"split_ratio"`
+}
+
+// validate alanları tek tek doğrular ve tüm hataları birlikte döner
+func (c ExpenseChanges) validate(db *gorm.DB, householdID uint) error {
+	v := &ValidationError{}
+	if c.CategoryID != nil {
+		if err := validateCategory(db, v, *c.CategoryID); err != nil {
+			return err
+		}
+	}
+	if c.Description != nil {
+		validateDescription(v, *c.Description)
+	}
+	if c.Amount != nil && *c.Amount <= 0 {
+		v.add("amount", "tutar sıfırdan büyük olmalı")
+	}
+	if c.PaidBy != nil {
+		if err := validatePayer(db, v, householdID, *c.PaidBy); err != nil {
+			return err
+		}
+	}
+	if c.Date != nil {
+		if _, err := time.Parse("2006-01-02", *c.Date); err != nil {
+			v.add("date", "tarih YYYY-AA-GG biçiminde olmalı")
+		}
+	}
+	if c.Month != nil && (*c.Month < 1 || *c.Month > 12) {
+		v.add("month", "ay 1 ile 12 arasında olmalı")
+	}
+	if c.Year != nil && (*c.Year < 2000 || *c.Year > 2100) {
+		v.add("year", "yıl 2000 ile 2100 arasında olmalı")
+	}
+	if c.SplitMode != nil {
+		switch *c.SplitMode {
+		case models.SplitEqual, models.SplitShares, models.SplitPercentage, models.SplitExact:
+		default:
+			v.add("split_mode", "geçersiz bölüşüm modu")
+		}
+	}
+	if c.SplitRatio != nil {
+		validateRatio(v, "split_ratio", *c.SplitRatio)
+	}
+	for i, in := range c.Splits {
+		if in.Percentage != 0 {
+			validateRatio(v, fmt.Sprintf("splits[%d].percentage", i), in.Percentage)
+		}
+		if in.Shares < 0 {
+			v.add(fmt.Sprintf("splits[%d].shares", i), "pay sayısı negatif olamaz")
+		}
+		if in.Amount < 0 {
+			v.add(fmt.Sprintf("splits[%d].amount", i), "pay tutarı negatif olamaz")
+		}
+	}
+	return v.result()
+}
+
+// columns değişen alanları kolon adlarıyla döner
+func (c ExpenseChanges) columns() map[string]interface{} {
+	cols := map[string]interface{}{}
+	if c.CategoryID != nil {
+		cols["category_id"] = *c.CategoryID
+	}
+	if c.Description != nil {
+		cols["description"] = strings.TrimSpace(*c.Description)
+	}
+	if c.Amount != nil {
+		cols["amount"] = *c.Amount
+	}
+	if c.PaidBy != nil {
+		cols["paid_by"] = *c.PaidBy
+	}
+	if c.IsShared != nil {
+		cols["is_shared"] = *c.IsShared
+	}
+	return cols
+}
+
+// dated değişiklik sonrası gider tarihi ve dönemi. Yeni tarih verilip dönem verilmezse dönem
+// tarihten çıkar. Sadece dönem değişir ve mevcut tarih o döneme uymazsa tarih dönemin ilk günü olur.
+func (c ExpenseChanges) dated(cal *periodCalendar, date time.Time, month, year int) (time.Time, int, int, error) {
+	if c.Date == nil && c.Month == nil && c.Year == nil {
+		return date, month, year, nil
+	}
+	if c.Date != nil {
+		date, _ = time.Parse("2006-01-02", *c.Date)
+		if c.Month == nil && c.Year == nil {
+			month, year = 0, 0
+		}
+	}
+	if c.Month != nil {
+		month = *c.Month
+	}
+	if c.Year != nil {
+		year = *c.Year
+	}
+	m, y, err := expensePeriod(cal, date, month, year)
+	if err == nil {
+		return date, m, y, nil
+	}
+	if c.Date != nil {
+		return date, 0, 0, &ValidationError{Fields: map[string]string{"month": err.Error()}}
+	}
+	first, _ := cal.bounds(month, year)
+	return first, month, year, nil
+}
+
+// splitSpec değişiklikte bölüşüm varsa onu, yoksa mevcut tanımı döner
+func (c ExpenseChanges) splitSpec(current SplitSpec) SplitSpec {
+	if c.SplitMode == nil && c.Splits == nil && c.SplitRatio == nil {
+		return current
+	}
+	spec := SplitSpec{Splits: c.Splits}
+	if c.SplitMode != nil {
+		spec.Mode = *c.SplitMode
+	}
+	spec.Ratio = c.SplitRatio
+	return spec
+}
+
+// RecurringChanges şablon düzenlemesi. Durum, onay ve taksit sayaçları iş akışına ait
+// olduğu için buradan değiştirilemez.
+type RecurringChanges struct {
+	CategoryID  *uint         `json:"category_id"`
+	Description *string       `json:"description"`
+	Amount      *models.Money `json:"amount"`
+	TotalAmount *models.Money `json:"total_amount"`
+	PaidBy      *uint         `json:"paid_by"`
+	IsShared    *bool         `json:"is_shared"`
+	SplitRatio  *float64      `json:"split_ratio"`
+}
+
+func (c RecurringChanges) validate(db *gorm.DB, householdID uint) error {
+	v := &ValidationError{}
+	if c.CategoryID != nil {
+		if err := validateCategory(db, v, *c.CategoryID); err != nil {
+			return err
+		}
+	}
+	if c.Description != nil {
+		validateDescription(v, *c.Description)
+	}
+	if c.Amount != nil && *c.Amount <= 0 {
+		v.add("amount", "tutar sıfırdan büyük olmalı")
+	}
+	if c.TotalAmount != nil && *c.TotalAmount <= 0 {
+		v.add("total_amount", "toplam tutar sıfırdan büyük olmalı")
+	}
+	if c.PaidBy != nil {
+		if err := validatePayer(db, v, householdID, *c.PaidBy); err != nil {
+			return err
+		}
+	}
+	if c.SplitRatio != nil {
+		validateRatio(v, "split_ratio", *c.SplitRatio)
+	}
+	return v.result()
+}
+
+func (c RecurringChanges) columns() map[string]interface{} {
+	cols := map[string]interface{}{}
+	if c.CategoryID != nil {
+		cols["category_id"] = *c.CategoryID
+	}
+	if c.Description != nil {
+		cols["description"] = strings.TrimSpace(*c.Description)
+	}
+	if c.Amount != nil {
+		cols["amount"] = *c.Amount
+	}
+	if c.TotalAmount != nil {
+		cols["total_amount"] = *c.TotalAmount
+	}
+	if c.PaidBy != nil {
+		cols["paid_by"] = *c.PaidBy
+	}
+	if c.IsShared != nil {
+		cols["is_shared"] = *c.IsShared
+	}
+	if c.SplitRatio != nil {
+		cols["split_ratio"] = *c.SplitRatio
+	}
+	return cols
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrPeriodClosed kapatılmış bir aya yazma denendiğinde döner
+var ErrPeriodClosed = errors.New("bu ay kapatılmış; değişiklik için önce yeniden açılmalı")
+
+type PeriodService struct {
+	db *gorm.DB
+}
+
+func NewPeriodService(db *gorm.DB) *PeriodService {
+	return &PeriodService{db: db}
+}
+
+// ensurePeriodOpen ay kapatılmışsa ErrPeriodClosed döner. Yazma transaction'ı içinde çağrılmalıdır:
+// dönem satırı transaction sonuna kadar kilitlenir, böylece kontrol ile yazma arasında ay kapanamaz.
+func ensurePeriodOpen(db *gorm.DB, householdID uint, month, year int) error {
+	closed, err := periodClosed(db.Scopes(forUpdate), householdID, month, year)
+	if err != nil {
+		return err
+	}
+	if closed {
+		return ErrPeriodClosed
+	}
+	return nil
+}
+
+// periodClosed ayın kapalı olup olmadığını kilit almadan okur; yazmadan önceki kontroller
+// için ensurePeriodOpen kullanılmalıdır
+func periodClosed(db *gorm.DB, householdID uint, month, year int) (bool, error) {
+	var lock models.PeriodLock
+	err := db.Scopes(inHousehold(householdID)).
+		Where("month = ? AND year = ?", month, year).
+		Limit(1).Find(&lock).Error
+	if err != nil {
+		return false, err
+	}
+	return lock.ID != 0 && lock.IsLocked(), nil
+}
+
+func validPeriod(month, year int) error {
+	if month < 1 || month > 12 || year < 2000 || year > 2100 {
+		return errors.New("geçersiz dönem")
+	}
+	return nil
+}
+
+func (s *PeriodService) List(householdID uint) ([]models.PeriodLock, error) {
+	var locks []models.PeriodLock
+	err := s.db.Scopes(inHousehold(householdID)).Preload("Requester").
+		Order("year DESC, month DESC").
+		Find(&locks).Error
+	return locks, err
+}
+
+func (s *PeriodService) Get(householdID uint, month, year int) (*models.PeriodLock, error) {
+	var lock models.PeriodLock
+	err := s.db.Scopes(inHousehold(householdID)).Preload("Requester").
+		Where("month = ? AND year = ?", month, year).
+		First(&lock).Error
+	return &lock, err
+}
+
+// findOrInit dönem satırını kilitleyerek okur; yoksa açık durumda yeni bir satır hazırlar
+func findOrInitLock(tx *gorm.DB, householdID uint, month, year int) (*models.PeriodLock, error) {
+	var lock models.PeriodLock
+	err := tx.Scopes(inHousehold(householdID), forUpdate).
+		Where("month = ? AND year = ?", month, year).
+		Limit(1).Find(&lock).Error
+	if err != nil {
+		return nil, err
+	}
+	if lock.ID == 0 {
+		lock = models.PeriodLock{HouseholdID: householdID, Month: month, Year: year, Status: models.PeriodOpen}
+	}
+	return &lock, nil
+}
+
+// Close ayı kapatma talebi oluşturur; talep başka bir üye tarafından tekrarlanırsa ay kapanır
+func (s *PeriodService) Close(householdID uint, month, year int, userID uint) error {
+	if err := validPeriod(month, year); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		lock, err := findOrInitLock(tx, householdID, month, year)
+		if err != nil {
+			return err
+		}
+
+		switch lock.Status {
+		case models.PeriodClosed, models.PeriodReopenRequested:
+			return errors.New("bu ay zaten kapatılmış")
+		case models.PeriodCloseRequested:
+			if lock.RequestedBy != nil && *lock.RequestedBy == userID {
+				return errors.New("kendi kapatma talebinizi onaylayamazsınız")
+			}
+			if err := s.ensureSettled(tx, householdID, month, year); err != nil {
+				return err
+			}
+			now := time.Now()
+			lock.Status = models.PeriodClosed
+			lock.ClosedBy = &userID
+			lock.ClosedAt = &now
+			lock.RequestedBy = nil
+		default:
+			if err := s.ensureSettled(tx, householdID, month, year); err != nil {
+				return err
+			}
+			lock.Status = models.PeriodCloseRequested
+			lock.RequestedBy = &userID
+		}
+		return tx.Save(lock).Error
+	})
+}
+
+// Reopen kapalı ayı yeniden açma talebi oluşturur; başka bir üye onaylarsa ay açılır
+func (s *PeriodService) Reopen(householdID uint, month, year int, userID uint) error {
+	if err := validPeriod(month, year); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		lock, err := findOrInitLock(tx, householdID, month, year)
+		if err != nil {
+			return err
+		}
+
+		switch lock.Status {
+		case models.PeriodClosed:
+			lock.Status = models.PeriodReopenRequested
+			lock.RequestedBy = &userID
+		case models.PeriodReopenRequested:
+			if lock.RequestedBy != nil && *lock.RequestedBy == userID {
+				return errors.New("kendi açma talebinizi onaylayamazsınız")
+			}
+			lock.Status = models.PeriodOpen
+			lock.RequestedBy = nil
+			lock.ClosedBy = nil
+			lock.ClosedAt = nil
+		default:
+			return errors.New("bu ay kapalı değil")
+		}
+		return tx.Save(lock).Error
+	})
+}
+
+// CancelRequest bekleyen kapatma/açma talebini geri çeker. Talebi yalnızca talep eden ya da
+// hane yöneticisi geri çekebilir.
+func (s *PeriodService) CancelRequest(householdID uint, month, year int, userID uint, canManage bool) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		lock, err := findOrInitLock(tx, householdID, month, year)
+		if err != nil {
+			return err
+		}
+
+		switch lock.Status {
+		case models.PeriodCloseRequested:
+			lock.Status = models.PeriodOpen
+		case models.PeriodReopenRequested:
+			lock.Status = models.PeriodClosed
+		default:
+			return errors.New("bu ay için bekleyen talep yok")
+		}
+		if !canManage && (lock.RequestedBy == nil || *lock.RequestedBy != userID) {
+			return errors.New("sadece kendi talebinizi geri çekebilirsiniz")
+		}
+		lock.RequestedBy = nil
+		return tx.Save(lock).Error
+	})
+}
+
+// ensureSettled onay ya da silme onayı bekleyen gider, öneri veya ödeme varken ay kapatılamaz
+func (s *PeriodService) ensureSettled(tx *gorm.DB, householdID uint, month, year int) error {
+	var pending int64
+	err := tx.Model(&models.Expense{}).Scopes(inHousehold(householdID), inPeriod(month, year)).
+		Where("status = ? OR delete_requested_by IS NOT NULL", models.StatusPending).
+		Count(&pending).Error
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return errors.New("onay bekleyen giderler varken ay kapatılamaz")
+	}
+
+	err = tx.Model(&models.ExpenseRevision{}).
+		Joins("JOIN expenses ON expenses.id = expense_revisions.expense_id").
+		Where("expenses.household_id = ? AND expense_revisions.status = ?", householdID, models.StatusPending).
+		Where("(expenses.expense_month = ? AND expenses.expense_year = ?) OR (expense_revisions.expense_month = ? AND expense_revisions.expense_year = ?)",
+			month, year, month, year).
+		Count(&pending).Error
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return errors.New("bekleyen değişiklik önerileri varken ay kapatılamaz")
+	}
+
+	err = tx.Model(&models.Payment{}).Scopes(inHousehold(householdID)).
+		Where("month = ? AND year = ?", month, year).
+		Where("status = ? OR delete_requested_by IS NOT NULL", models.StatusPending).
+		Count(&pending).Error
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return errors.New("onay bekleyen ödemeler varken ay kapatılamaz")
+	}
+	return nil
+}
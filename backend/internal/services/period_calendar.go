@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+// periodCalendar hanenin muhasebe dönemi sınırları. Dönem başladığı ayın adıyla anılır:
+// başlangıç günü 15 ise Mart dönemi 15 Mart – 14 Nisan arasıdır. Tüm dönem hesapları
+// buradan yapılır.
+type periodCalendar struct {
+	startDay int
+	loc      *time.Location
+}
+
+// calendarFor hane ayarlarından dönem takvimini kurar. Saat dilimi boşsa sunucunun saat
+// dilimi kullanılır.
+func calendarFor(household *models.Household) *periodCalendar {
+	cal := &periodCalendar{startDay: household.PeriodStartDay, loc: time.Local}
+	if cal.startDay < 1 || cal.startDay > 28 {
+		cal.startDay = 1
+	}
+	if household.Timezone != "" {
+		if loc, err := time.LoadLocation(household.Timezone); err == nil {
+			cal.loc = loc
+		}
+	}
+	return cal
+}
+
+// householdCalendar hanenin dönem takvimini okur
+func householdCalendar(db *gorm.DB, householdID uint) (*periodCalendar, error) {
+	var household models.Household
+	if err := db.First(&household, householdID).Error; err != nil {
+		return nil, err
+	}
+	return calendarFor(&household), nil
+}
+
+// dateAt verilen anın hanenin saat dilimindeki tarihi
+func (c *periodCalendar) dateAt(t time.Time) time.Time {
+	return dateOnly(t.In(c.loc))
+}
+
+// today hanenin saat dilimine göre bugün
+func (c *periodCalendar) today() time.Time {
+	return c.dateAt(time.Now())
+}
+
+// periodOf tarihin düştüğü dönem
+func (c *periodCalendar) periodOf(date time.Time) (int, int) {
+	if date.Day() < c.startDay {
+		date = monthStart(date).AddDate(0, -1, 0)
+	}
+	return int(date.Month()), date.Year()
+}
+
+// current bugünün dönemi
+func (c *periodCalendar) current() (int, int) {
+	return c.periodOf(c.today())
+}
+
+// bounds dönemin ilk ve son günü (iki uç dahil)
+func (c *periodCalendar) bounds(month, year int) (time.Time, time.Time) {
+	from := time.Date(year, time.Month(month), c.startDay, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, -1)
+}
+
+// resolve boş bırakılan dönemi bugünün dönemiyle doldurur
+func (c *periodCalendar) resolve(month, year int) (int, int) {
+	if month == 0 || year == 0 {
+		return c.current()
+	}
+	return month, year
+}
+
+// previousPeriod bir önceki dönem
+func previousPeriod(month, year int) (int, int) {
+	if month == 1 {
+		return 12, year - 1
+	}
+	return month - 1, year
+}
+
+// nextPeriod bir sonraki dönem
+func nextPeriod(month, year int) (int, int) {
+	if month == 12 {
+		return 1, year + 1
+	}
+	return month + 1, year
+}
+
+// inPeriod gider sorgusunu verilen döneme ait kayıtlarla sınırlar
+func inPeriod(month, year int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("expense_month = ? AND expense_year = ?", month, year)
+	}
+}
+
+// periodKey ay/yıl çiftini karşılaştırılabilir tek bir sayıya çevirir
+func periodKey(month, year int) int {
+	return year*12 + month
+}
+
+// expensePeriod gider tarihinin ait olduğu dönemi döner. Dönem verilirse ("geçen aya ait")
+// tarihin dönemi ya da bir önceki dönem olabilir.
+func expensePeriod(cal *periodCalendar, date time.Time, month, year int) (int, int, error) {
+	dm, dy := cal.periodOf(date)
+	if month == 0 && year == 0 {
+		return dm, dy, nil
+	}
+	if err := validPeriod(month, year); err != nil {
+		return 0, 0, err
+	}
+	pm, py := previousPeriod(dm, dy)
+	if (month != dm || year != dy) && (month != pm || year != py) {
+		return 0, 0, errors.New("gider dönemi, tarihin dönemi ya da bir önceki dönem olabilir")
+	}
+	return month, year, nil
+}
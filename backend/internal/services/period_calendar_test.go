@@ -0,0 +1,144 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+)
+
+func TestCalendarFor(t *testing.T) {
+	tests := []struct {
+		household models.Household
+		startDay  int
+		zone      string
+	}{
+		{models.Household{PeriodStartDay: 15, Timezone: "Europe/Istanbul"}, 15, "Europe/Istanbul"},
+		{models.Household{PeriodStartDay: 0}, 1, time.Local.String()},
+		{models.Household{PeriodStartDay: 31, Timezone: "Bilinmeyen/Yer"}, 1, time.Local.String()},
+	}
+	for _, tt := range tests {
+		cal := calendarFor(&tt.household)
+		if cal.startDay != tt.startDay || cal.loc.String() != tt.zone {
+			t.Errorf("%+v: başlangıç %d, dilim %s; beklenen %d, %s", tt.household, cal.startDay, cal.loc, tt.startDay, tt.zone)
+		}
+	}
+}
+
+func TestPeriodOf(t *testing.T) {
+	tests := []struct {
+		startDay int
+		date     time.Time
+		month    int
+		year     int
+	}{
+		{1, day(2025, 3, 1), 3, 2025},
+		{1, day(2025, 3, 31), 3, 2025},
+		{15, day(2025, 3, 14), 2, 2025},
+		{15, day(2025, 3, 15), 3, 2025},
+		{15, day(2025, 4, 14), 3, 2025},
+		{15, day(2026, 1, 10), 12, 2025},
+		{15, day(2025, 12, 31), 12, 2025},
+		{28, day(2025, 3, 1), 2, 2025},
+	}
+	for _, tt := range tests {
+		cal := &periodCalendar{startDay: tt.startDay, loc: time.UTC}
+		m, y := cal.periodOf(tt.date)
+		if m != tt.month || y != tt.year {
+			t.Errorf("başlangıç %d, %s: %d/%d, beklenen %d/%d", tt.startDay, tt.date.Format("2006-01-02"), m, y, tt.month, tt.year)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	tests := []struct {
+		startDay    int
+		month, year int
+		from, to    time.Time
+	}{
+		{1, 2, 2024, day(2024, 2, 1), day(2024, 2, 29)},
+		{15, 3, 2025, day(2025, 3, 15), day(2025, 4, 14)},
+		{15, 12, 2025, day(2025, 12, 15), day(2026, 1, 14)},
+		{28, 1, 2025, day(2025, 1, 28), day(2025, 2, 27)},
+	}
+	for _, tt := range tests {
+		cal := &periodCalendar{startDay: tt.startDay, loc: time.UTC}
+		from, to := cal.bounds(tt.month, tt.year)
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("başlangıç %d, %d/%d: %s – %s", tt.startDay, tt.month, tt.year, from.Format("2006-01-02"), to.Format("2006-01-02"))
+		}
+		// Sınırlar dönemin kendisine düşer, bir sonraki gün bir sonraki döneme
+		if m, y := cal.periodOf(from); m != tt.month || y != tt.year {
+			t.Errorf("başlangıç %d: ilk gün %d/%d dönemine düştü", tt.startDay, m, y)
+		}
+		if m, y := cal.periodOf(to); m != tt.month || y != tt.year {
+			t.Errorf("başlangıç %d: son gün %d/%d dönemine düştü", tt.startDay, m, y)
+		}
+		nm, ny := nextPeriod(tt.month, tt.year)
+		if m, y := cal.periodOf(to.AddDate(0, 0, 1)); m != nm || y != ny {
+			t.Errorf("başlangıç %d: son günden sonraki gün %d/%d dönemine düştü", tt.startDay, m, y)
+		}
+	}
+}
+
+// Gece yarısına yakın anlar hanenin saat dilimine göre tarihlenir
+func TestDateAtTimezone(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		t.Skip("saat dilimi verisi yok:", err)
+	}
+	cal := &periodCalendar{startDay: 1, loc: istanbul}
+	utc := &periodCalendar{startDay: 1, loc: time.UTC}
+
+	// 31 Mart 22:30 UTC İstanbul'da 1 Nisan 01:30'dur
+	instant := time.Date(2025, 3, 31, 22, 30, 0, 0, time.UTC)
+	if got := cal.dateAt(instant); !got.Equal(day(2025, 4, 1)) {
+		t.Errorf("İstanbul tarihi %s, beklenen 2025-04-01", got.Format("2006-01-02"))
+	}
+	if got := utc.dateAt(instant); !got.Equal(day(2025, 3, 31)) {
+		t.Errorf("UTC tarihi %s, beklenen 2025-03-31", got.Format("2006-01-02"))
+	}
+	if m, _ := cal.periodOf(cal.dateAt(instant)); m != 4 {
+		t.Errorf("İstanbul dönemi %d, beklenen 4", m)
+	}
+
+	// Yıl dönümü: 31 Aralık 21:00 UTC İstanbul'da yeni yıldır
+	instant = time.Date(2025, 12, 31, 21, 0, 0, 0, time.UTC)
+	if m, y := cal.periodOf(cal.dateAt(instant)); m != 1 || y != 2026 {
+		t.Errorf("yıl dönümü dönemi %d/%d, beklenen 1/2026", m, y)
+	}
+	if m, y := utc.periodOf(utc.dateAt(instant)); m != 12 || y != 2025 {
+		t.Errorf("UTC yıl dönümü dönemi %d/%d, beklenen 12/2025", m, y)
+	}
+}
+
+func TestExpensePeriod(t *testing.T) {
+	cal := &periodCalendar{startDay: 15, loc: time.UTC}
+	tests := []struct {
+		name        string
+		date        time.Time
+		month, year int
+		wantMonth   int
+		wantYear    int
+		wantErr     bool
+	}{
+		{"dönem verilmezse tarihten", day(2025, 3, 20), 0, 0, 3, 2025, false},
+		{"başlangıç gününden önce önceki dönem", day(2025, 3, 10), 0, 0, 2, 2025, false},
+		{"tarihin dönemi", day(2025, 3, 20), 3, 2025, 3, 2025, false},
+		{"geçen aya ait", day(2025, 3, 20), 2, 2025, 2, 2025, false},
+		{"yıl dönümünde geçen aya ait", day(2026, 1, 20), 12, 2025, 12, 2025, false},
+		{"iki dönem önce olamaz", day(2025, 3, 20), 1, 2025, 0, 0, true},
+		{"sonraki dönem olamaz", day(2025, 3, 20), 4, 2025, 0, 0, true},
+		{"geçersiz dönem", day(2025, 3, 20), 13, 2025, 0, 0, true},
+	}
+	for _, tt := range tests {
+		m, y, err := expensePeriod(cal, tt.date, tt.month, tt.year)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: hata = %v", tt.name, err)
+			continue
+		}
+		if m != tt.wantMonth || y != tt.wantYear {
+			t.Errorf("%s: %d/%d, beklenen %d/%d", tt.name, m, y, tt.wantMonth, tt.wantYear)
+		}
+	}
+}
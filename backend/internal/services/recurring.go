@@ -2,10 +2,14 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
+	"github.com/caner/home-gider/internal/database"
 	"github.com/caner/home-gider/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RecurringService struct {
@@ -16,49 +20,161 @@ func NewRecurringService(db *gorm.DB) *RecurringService {
 	return &RecurringService{db: db}
 }
 
-func (s *RecurringService) List() ([]models.RecurringExpense, error) {
+func (s *RecurringService) List(householdID uint) ([]models.RecurringExpense, error) {
 	var items []models.RecurringExpense
-	err := s.db.Preload("Creator").Preload("Category").Preload("Approver").
+	err := s.db.Scopes(inHousehold(householdID)).Preload("Creator").Preload("Payer").Preload("Category").Preload("Approver").
 		Order("created_at DESC").Find(&items).Error
 	return items, err
 }
 
 func (s *RecurringService) Create(item *models.RecurringExpense) error {
+	if item.PaidBy == 0 {
+		item.PaidBy = item.CreatedBy
+	}
+	if err := ensurePayer(s.db, item.HouseholdID, item.PaidBy); err != nil {
+		return err
+	}
+	if item.Frequency == "" {
+		item.Frequency = models.FrequencyMonthly
+	}
+	if item.Interval == 0 {
+		item.Interval = 1
+	}
+	if item.StartDate.IsZero() {
+		item.StartDate = dateOnly(time.Now())
+	}
+	if err := validateSchedule(item); err != nil {
+		return err
+	}
+	switch item.Type {
+	case models.TypeRecurring, models.TypeInstallment:
+	case models.TypeVariable:
+		if item.EstimateWindow == 0 {
+			item.EstimateWindow = defaultEstimateWindow
+		}
+		if item.EstimateWindow < 1 || item.EstimateWindow > 24 {
+			return errors.New("tahmin için kullanılacak ay sayısı 1 ile 24 arasında olmalı")
+		}
+	default:
+		return errors.New("geçersiz şablon tipi")
+	}
 	if item.Type == models.TypeInstallment {
-		if item.InstallmentCount == nil || *item.InstallmentCount <= 0 {
-			return errors.New("taksit sayısı belirtilmelidir")
+		if err := planInstallments(item); err != nil {
+			return err
 		}
-		remaining := *item.InstallmentCount
-		item.InstallmentsRemaining = &remaining
 	}
-	return s.db.Create(item).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+
+		// İlk sürüm şablonla birlikte onaylanır
+		rev := recurringRevisionFrom(item)
+		rev.Version = 1
+		rev.ProposedBy = item.CreatedBy
+		rev.Status = models.StatusPending
+		if err := tx.Create(&rev).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(item).Update("current_revision_id", rev.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, item.HouseholdID, item.CreatedBy, auditRecurring, item.ID, models.AuditCreate, nil, item)
+	})
 }
 
-func (s *RecurringService) Update(id, userID uint, updates map[string]interface{}) error {
+// Update şablonu düzenler; sadece RecurringChanges alanları değiştirilebilir. Henüz onaylanmamış
+// şablon doğrudan değişir. Onaylı şablonda değişiklik bekleyen bir sürüm olarak döner ve
+// diğer üye onaylayana kadar mevcut sürüm geçerli kalır.
+func (s *RecurringService) Update(householdID, id, userID uint, changes RecurringChanges) (*models.RecurringRevision, error) {
 	var item models.RecurringExpense
-	if err := s.db.First(&item, id).Error; err != nil {
-		return err
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
+		return nil, err
 	}
 	if item.CreatedBy != userID {
-		return errors.New("sadece kendi oluşturduğunuz şablonları düzenleyebilirsiniz")
+		return nil, errors.New("sadece kendi oluşturduğunuz şablonları düzenleyebilirsiniz")
+	}
+	if err := changes.validate(s.db, householdID); err != nil {
+		return nil, err
+	}
+	// Taksit tutarları plandan hesaplanır; değişiklik yeniden yapılandırmayla yapılır
+	if item.Type == models.TypeInstallment && (changes.Amount != nil || changes.TotalAmount != nil) {
+		return nil, &ValidationError{Fields: map[string]string{
+			"amount": "taksit tutarı plandan hesaplanır; yeniden yapılandırma kullanın",
+		}}
+	}
+	updates := changes.columns()
+	if len(updates) == 0 {
+		return nil, nil
+	}
+
+	switch item.Status {
+	case models.StatusApproved:
+		return s.proposeRevision(&item, userID, changes)
+	case models.StatusRejected:
+		return nil, errors.New("reddedilmiş şablon düzenlenemez")
 	}
-	return s.db.Model(&item).Updates(updates).Error
+
+	before := item
+	return nil, s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&item).Updates(updates).Error; err != nil {
+			return err
+		}
+		// Onay bekleyen ilk sürüm de aynı değerleri taşır
+		if item.CurrentRevisionID != nil {
+			err := tx.Model(&models.RecurringRevision{}).
+				Where("id = ?", *item.CurrentRevisionID).
+				Updates(updates).Error
+			if err != nil {
+				return err
+			}
+		}
+		var after models.RecurringExpense
+		if err := tx.First(&after, item.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditRecurring, item.ID, models.AuditUpdate, &before, &after)
+	})
 }
 
-func (s *RecurringService) Delete(id, userID uint) error {
+// reviewCurrentRevision şablon onaylanır ya da reddedilirse ilk sürüm de aynı sonucu alır
+func reviewCurrentRevision(tx *gorm.DB, item *models.RecurringExpense, status models.ExpenseStatus, reviewerID uint) error {
+	if item.CurrentRevisionID == nil {
+		return nil
+	}
+	return tx.Model(&models.RecurringRevision{}).
+		Where("id = ? AND status = ?", *item.CurrentRevisionID, models.StatusPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": reviewerID,
+			"reviewed_at": time.Now(),
+		}).Error
+}
+
+func (s *RecurringService) Delete(householdID, id, userID uint) error {
 	var item models.RecurringExpense
-	if err := s.db.First(&item, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
 		return err
 	}
 	if item.CreatedBy != userID {
 		return errors.New("sadece kendi oluşturduğunuz şablonları silebilirsiniz")
 	}
-	return s.db.Model(&item).Update("is_active", false).Error
+
+	// Şablon silinmez, pasife alınır
+	before := item
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&item).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		after := before
+		after.IsActive = false
+		return recordAudit(tx, householdID, userID, auditRecurring, item.ID, models.AuditDelete, &before, &after)
+	})
 }
 
-func (s *RecurringService) Approve(id, approverID uint, isAdmin bool) error {
+func (s *RecurringService) Approve(householdID, id, approverID uint, isAdmin bool) error {
 	var item models.RecurringExpense
-	if err := s.db.First(&item, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
 		return err
 	}
 	if !isAdmin && item.CreatedBy == approverID {
@@ -68,20 +184,34 @@ func (s *RecurringService) Approve(id, approverID uint, isAdmin bool) error {
 		return errors.New("bu şablon zaten işlenmiş")
 	}
 
-	if err := s.db.Model(&item).Updates(map[string]interface{}{
-		"status":      models.StatusApproved,
-		"approved_by": approverID,
-	}).Error; err != nil {
-		return err
-	}
+	before := item
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&item).Updates(map[string]interface{}{
+			"status":      models.StatusApproved,
+			"approved_by": approverID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := reviewCurrentRevision(tx, &item, models.StatusApproved, approverID); err != nil {
+			return err
+		}
+		after := before
+		after.Status = models.StatusApproved
+		after.ApprovedBy = &approverID
+		if err := recordAudit(tx, householdID, approverID, auditRecurring, item.ID, models.AuditApprove, &before, &after); err != nil {
+			return err
+		}
 
-	// Onaylandığında hemen bu ay için gider oluştur
-	return s.createExpenseForMonth(&item, time.Now())
+		// Onaylandığında başlangıç tarihinden bugüne vadesi gelmiş tekrarlar hemen oluşturulur
+		_, err = s.generateDue(tx, &item, resumeFrom(&item), time.Now(), approverID)
+		return err
+	})
 }
 
-func (s *RecurringService) Reject(id, approverID uint, isAdmin bool) error {
+func (s *RecurringService) Reject(householdID, id, approverID uint, isAdmin bool) error {
 	var item models.RecurringExpense
-	if err := s.db.First(&item, id).Error; err != nil {
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
 		return err
 	}
 	if !isAdmin && item.CreatedBy == approverID {
@@ -90,29 +220,130 @@ func (s *RecurringService) Reject(id, approverID uint, isAdmin bool) error {
 	if item.Status != models.StatusPending {
 		return errors.New("bu şablon zaten işlenmiş")
 	}
-	return s.db.Model(&item).Update("status", models.StatusRejected).Error
+
+	before := item
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&item).Update("status", models.StatusRejected).Error; err != nil {
+			return err
+		}
+		if err := reviewCurrentRevision(tx, &item, models.StatusRejected, approverID); err != nil {
+			return err
+		}
+		after := before
+		after.Status = models.StatusRejected
+		return recordAudit(tx, householdID, approverID, auditRecurring, item.ID, models.AuditReject, &before, &after)
+	})
 }
 
-// createExpenseForMonth verilen ay için gider kaydı oluşturur
-func (s *RecurringService) createExpenseForMonth(item *models.RecurringExpense, date time.Time) error {
-	month := int(date.Month())
-	year := date.Year()
+// defaultEstimateWindow değişken faturada tahmin için bakılan son gerçek tutar sayısı
+const defaultEstimateWindow = 3
 
-	// Bu ay için zaten kayıt var mı?
+// estimateAmount şablonun son EstimateWindow gerçek tutarının ortalamasını döner.
+// Henüz gerçek tutar yoksa şablondaki tutar kullanılır.
+func estimateAmount(tx *gorm.DB, item *models.RecurringExpense) (models.Money, error) {
+	window := item.EstimateWindow
+	if window < 1 {
+		window = defaultEstimateWindow
+	}
+	var amounts []models.Money
+	err := tx.Model(&models.Expense{}).
+		Where("recurring_expense_id = ? AND is_estimate = ? AND status = ?", item.ID, false, models.StatusApproved).
+		Order("occurrence_date DESC, id DESC").
+		Limit(window).
+		Pluck("amount", &amounts).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(amounts) == 0 {
+		return item.Amount, nil
+	}
+	var total models.Money
+	for _, a := range amounts {
+		total += a
+	}
+	// Kuruşa yuvarlanmış ortalama
+	return (total + models.Money(len(amounts))/2) / models.Money(len(amounts)), nil
+}
+
+// generateDue şablonun from tarihinden to anına (hanenin saat dilimindeki gün) kadar vadesi
+// gelmiş ve henüz oluşturulmamış tekrarlarını oluşturur. Kapatılmış dönemlere düşen tekrarlar atlanır.
+func (s *RecurringService) generateDue(tx *gorm.DB, item *models.RecurringExpense, from, to time.Time, actorID uint) (int, error) {
+	// Şablon satırı kilitlenip güncel hâli okunur; taksit sayacı ve son üretim tarihi
+	// aynı anda çalışan başka bir işlemle yarışmaz
+	if err := tx.Scopes(forUpdate).First(item, item.ID).Error; err != nil {
+		return 0, err
+	}
+	// Erken kapama onay beklerken taksit üretilmez
+	if !item.IsActive || item.Status != models.StatusApproved || item.PayoffExpenseID != nil {
+		return 0, nil
+	}
+	filter, err := loadOccurrenceFilter(tx, item)
+	if err != nil {
+		return 0, err
+	}
+	today := filter.cal.dateAt(to)
+
+	created := 0
+	for _, date := range dueOccurrences(item, from, today) {
+		if !item.IsActive {
+			break
+		}
+		ok, err := s.createOccurrence(tx, item, filter, date, actorID)
+		if err != nil {
+			if errors.Is(err, ErrPeriodClosed) {
+				log.Printf("Şablon %d, %s tekrarı atlandı: %v", item.ID, date.Format("2006-01-02"), err)
+				continue
+			}
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	// Bugüne kadarki tekrarlar işlendi; sonraki çalışma buradan devam eder
+	if item.LastGeneratedPeriod == nil || item.LastGeneratedPeriod.Before(today) {
+		if err := tx.Model(item).Update("last_generated_period", today).Error; err != nil {
+			return created, err
+		}
+		item.LastGeneratedPeriod = &today
+	}
+	return created, nil
+}
+
+// createOccurrence şablonun verilen vade tarihindeki gider kaydını oluşturur ve kayıt
+// oluşturulduysa true döner. Aynı tekrar iki kez oluşturulmaz. Çağıranın transaction'ı
+// içinde çalışır; actorID zamanlanmış işte 0'dır.
+func (s *RecurringService) createOccurrence(tx *gorm.DB, item *models.RecurringExpense, filter *occurrenceFilter, date time.Time, actorID uint) (bool, error) {
+	month, year := filter.cal.periodOf(date)
+
+	// Bu tekrar için zaten kayıt var mı?
 	var count int64
-	s.db.Model(&models.Expense{}).
-		Where("recurring_expense_id = ? AND expense_month = ? AND expense_year = ?",
-			item.ID, month, year).
-		Count(&count)
+	err := tx.Model(&models.Expense{}).
+		Where("recurring_expense_id = ? AND occurrence_date = ?", item.ID, date).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
 	if count > 0 {
-		return nil
+		return false, nil
+	}
+
+	// Duraklatılmış ya da atlanan dönemde gider üretilmez
+	if state, _ := filter.check(date); state != occurrenceScheduled {
+		return false, nil
+	}
+
+	// Kapatılmış aya gider yazılmaz
+	if err := ensurePeriodOpen(tx, item.HouseholdID, month, year); err != nil {
+		return false, err
 	}
 
 	// Taksit kontrolü
 	if item.Type == models.TypeInstallment {
 		if item.InstallmentsRemaining != nil && *item.InstallmentsRemaining <= 0 {
-			s.db.Model(item).Update("is_active", false)
-			return nil
+			item.IsActive = false
+			return false, tx.Model(item).Update("is_active", false).Error
 		}
 	}
 
@@ -123,58 +354,246 @@ func (s *RecurringService) createExpenseForMonth(item *models.RecurringExpense,
 		installmentTotal = item.InstallmentCount
 	}
 
+	// Değişken tutarlı faturada tahmini tutarla onay bekleyen bir kayıt açılır;
+	// gerçek tutarı ödeyen girer, diğer üye onaylar
+	amount := item.Amount
+	status := models.StatusApproved
+	if installmentNo > 0 {
+		amount = installmentAmount(item, installmentNo)
+	}
+	if item.Type == models.TypeVariable {
+		estimate, err := estimateAmount(tx, item)
+		if err != nil {
+			return false, err
+		}
+		amount = estimate
+		status = models.StatusPending
+	}
+
 	expense := models.Expense{
+		HouseholdID:        item.HouseholdID,
 		CreatedBy:          item.CreatedBy,
+		PaidBy:             item.PaidBy,
 		CategoryID:         item.CategoryID,
 		Description:        item.Description,
-		Amount:             item.Amount,
+		Amount:             amount,
+		IsEstimate:         item.Type == models.TypeVariable,
 		ExpenseDate:        date,
 		ExpenseMonth:       month,
 		ExpenseYear:        year,
+		OccurrenceDate:     &date,
 		IsShared:           item.IsShared,
-		SplitRatio:         item.SplitRatio,
 		IsInstallment:      item.Type == models.TypeInstallment,
 		RecurringExpenseID: &item.ID,
-		Status:             models.StatusApproved,
+		// Giderin hangi şablon sürümünden üretildiği saklanır
+		RecurringRevisionID: item.CurrentRevisionID,
+		Status:              status,
 	}
 	if installmentNo > 0 {
 		expense.InstallmentNo = &installmentNo
 		expense.InstallmentTotal = installmentTotal
 	}
 
-	if err := s.db.Create(&expense).Error; err != nil {
-		return err
+	mode, splits, err := buildSplits(tx, item.HouseholdID, item.CreatedBy, amount, item.IsShared, SplitSpec{Ratio: &item.SplitRatio})
+	if err != nil {
+		return false, err
+	}
+	expense.SplitMode = mode
+	expense.Splits = splits
+
+	// Aynı tekrar başka bir işlemle yazıldıysa unique index çakışması sessizce atlanır
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("Splits").Create(&expense)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	for i := range splits {
+		splits[i].ExpenseID = expense.ID
+	}
+	if err := tx.Create(&splits).Error; err != nil {
+		return false, err
+	}
+	if err := recordAudit(tx, item.HouseholdID, actorID, auditExpense, expense.ID, models.AuditGenerate, nil, &expense); err != nil {
+		return false, err
 	}
 
 	// Taksitte kalan sayıyı azalt
 	if item.Type == models.TypeInstallment && item.InstallmentsRemaining != nil {
+		before := *item
 		newRemaining := *item.InstallmentsRemaining - 1
-		updates := map[string]interface{}{"installments_remaining": newRemaining}
+		updates := map[string]interface{}{"installments_remaining": gorm.Expr("installments_remaining - 1")}
 		if newRemaining <= 0 {
 			updates["is_active"] = false
 		}
-		s.db.Model(item).Updates(updates)
+		if err := tx.Model(item).Updates(updates).Error; err != nil {
+			return false, err
+		}
+		item.InstallmentsRemaining = &newRemaining
+		item.IsActive = newRemaining > 0
+		after := before
+		after.InstallmentsRemaining = &newRemaining
+		after.IsActive = newRemaining > 0
+		return true, recordAudit(tx, item.HouseholdID, actorID, auditRecurring, item.ID, models.AuditUpdate, &before, &after)
+	}
+
+	return true, nil
+}
+
+// recurringLockKey birden fazla replika çalışırken işin tek bir yerde yürümesi için
+const recurringLockKey int64 = 4_242_002
+
+// resumeFrom şablonun üretime devam edeceği tarih: son üretilen günün ertesi
+func resumeFrom(item *models.RecurringExpense) time.Time {
+	if item.LastGeneratedPeriod != nil {
+		return item.LastGeneratedPeriod.AddDate(0, 0, 1)
+	}
+	return item.StartDate
+}
+
+// ProcessRecurring her gün çalışır — her şablon için son üretilen tarihten bugüne kadar
+// kaçırılmış tekrarları oluşturur ve oluşturulan gider sayısını döner. Sunucu ay dönümünde
+// kapalı kalsa bile eksik kalmaz. İş başka bir replikada çalışıyorsa ErrJobRunning döner.
+func (s *RecurringService) ProcessRecurring() (int, error) {
+	created := 0
+	acquired, err := database.TryAdvisoryLock(s.db, recurringLockKey, func(*gorm.DB) (err error) {
+		created, err = s.catchUp(time.Now(), resumeFrom)
+		return err
+	})
+	if err == nil && !acquired {
+		return 0, ErrJobRunning
 	}
+	return created, err
+}
 
-	return nil
+// PlannedOccurrence kuru çalıştırmada oluşturulacak (ya da atlanacak) bir tekrar
+type PlannedOccurrence struct {
+	RecurringExpenseID uint         `json:"recurring_expense_id"`
+	HouseholdID        uint         `json:"household_id"`
+	Description        string       `json:"description"`
+	Amount             models.Money `json:"amount"`
+	Date               time.Time    `json:"date"`
+	Skipped            string       `json:"skipped,omitempty"`
 }
 
-// ProcessRecurring her gün çalışır — bu ay için eksik gider kayıtlarını oluşturur
-func (s *RecurringService) ProcessRecurring() error {
+// PlanRecurring ProcessRecurring'in şu an çalışsa neler oluşturacağını hiçbir şey yazmadan döner
+func (s *RecurringService) PlanRecurring() ([]PlannedOccurrence, error) {
+	var items []models.RecurringExpense
+	err := s.db.Where("is_active = ? AND status = ? AND payoff_expense_id IS NULL",
+		true, models.StatusApproved).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
+	plan := []PlannedOccurrence{}
+	for _, item := range items {
+		// Taksitlerde kalan sayıdan fazlası üretilmez
+		remaining := -1
+		if item.Type == models.TypeInstallment && item.InstallmentsRemaining != nil {
+			remaining = *item.InstallmentsRemaining
+		}
+
+		amount := item.Amount
+		if item.Type == models.TypeVariable {
+			if amount, err = estimateAmount(s.db, &item); err != nil {
+				return nil, err
+			}
+		}
+		next := paidInstallments(&item) + 1
+		filter, err := loadOccurrenceFilter(s.db, &item)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, date := range dueOccurrences(&item, resumeFrom(&item), filter.cal.dateAt(now)) {
+			if remaining == 0 {
+				break
+			}
+			var count int64
+			err := s.db.Model(&models.Expense{}).
+				Where("recurring_expense_id = ? AND occurrence_date = ?", item.ID, date).
+				Count(&count).Error
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				continue
+			}
 
+			p := PlannedOccurrence{
+				RecurringExpenseID: item.ID,
+				HouseholdID:        item.HouseholdID,
+				Description:        item.Description,
+				Amount:             amount,
+				Date:               date,
+			}
+			if item.Type == models.TypeInstallment {
+				p.Amount = installmentAmount(&item, next)
+			}
+			if state, reason := filter.check(date); state != occurrenceScheduled {
+				p.Skipped = skippedLabel(state, reason)
+				plan = append(plan, p)
+				continue
+			}
+			month, year := filter.cal.periodOf(date)
+			// Önizleme bir şey yazmadığı için dönem kilitlenmeden okunur
+			closed, err := periodClosed(s.db, item.HouseholdID, month, year)
+			switch {
+			case err != nil:
+				return nil, err
+			case closed:
+				p.Skipped = ErrPeriodClosed.Error()
+			default:
+				remaining--
+				next++
+			}
+			plan = append(plan, p)
+		}
+	}
+	return plan, nil
+}
+
+// Backfill verilen tarihten bugüne kadar tüm aktif şablonların eksik tekrarlarını oluşturur.
+// Zaten oluşturulmuş tekrarlar atlandığı için tekrar çalıştırmak güvenlidir. Zamanlanmış iş
+// çalışıyorsa bitmesini bekler.
+func (s *RecurringService) Backfill(from time.Time) (int, error) {
+	created := 0
+	err := database.WithAdvisoryLock(s.db, recurringLockKey, func(*gorm.DB) (err error) {
+		created, err = s.catchUp(time.Now(), func(*models.RecurringExpense) time.Time {
+			return from
+		})
+		return err
+	})
+	return created, err
+}
+
+// catchUp her şablonu kendi transaction'ında işler ve oluşturulan gider sayısını döner.
+// Hata veren şablonlar atlanır ve hataları birlikte döner.
+func (s *RecurringService) catchUp(now time.Time, fromFn func(*models.RecurringExpense) time.Time) (int, error) {
 	var items []models.RecurringExpense
 	err := s.db.Where("is_active = ? AND status = ?",
 		true, models.StatusApproved).Find(&items).Error
 	if err != nil {
-		return err
+		return 0, err
 	}
 
+	// Bir şablonun hatası diğerlerini durdurmaz; hatalar toplanıp çalışma kaydına yazılır
+	total := 0
+	var failures []error
 	for _, item := range items {
-		if err := s.createExpenseForMonth(&item, now); err != nil {
+		var created int
+		err := s.db.Transaction(func(tx *gorm.DB) (err error) {
+			created, err = s.generateDue(tx, &item, fromFn(&item), now, 0)
 			return err
+		})
+		if err != nil {
+			log.Printf("Şablon %d işlenemedi: %v", item.ID, err)
+			failures = append(failures, fmt.Errorf("şablon %d: %w", item.ID, err))
+			continue
 		}
+		total += created
 	}
-
-	return nil
+	return total, errors.Join(failures...)
 }
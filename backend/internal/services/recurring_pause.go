@@ -0,0 +1,339 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+const auditRecurringSkip = "recurring_skip"
+
+// Önizlemedeki tekrar durumları
+const (
+	occurrenceScheduled = "scheduled"
+	occurrencePaused    = "paused"
+	occurrenceSkipped   = "skipped"
+)
+
+// maxUpcoming önizlemede gösterilebilecek en fazla tekrar sayısı
+const maxUpcoming = 24
+
+// UpcomingOccurrence şablonun ileriki bir tekrarı. Duraklatılmış ya da atlanmış tekrarlar da
+// nedenleriyle birlikte döner.
+type UpcomingOccurrence struct {
+	Date   time.Time    `json:"date"`
+	Amount models.Money `json:"amount"`
+	State  string       `json:"state"`
+	Reason string       `json:"reason,omitempty"`
+}
+
+// occurrenceFilter şablonun duraklatma aralıkları, atlanan dönemleri ve hanenin dönem takvimi
+type occurrenceFilter struct {
+	cal    *periodCalendar
+	pauses []models.RecurringPause
+	skips  []models.RecurringSkip
+}
+
+func loadOccurrenceFilter(db *gorm.DB, item *models.RecurringExpense) (*occurrenceFilter, error) {
+	cal, err := householdCalendar(db, item.HouseholdID)
+	if err != nil {
+		return nil, err
+	}
+	f := &occurrenceFilter{cal: cal}
+	if err := db.Where("recurring_expense_id = ?", item.ID).Find(&f.pauses).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("recurring_expense_id = ?", item.ID).Find(&f.skips).Error; err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// check tekrarın üretilip üretilmeyeceğini ve atlanıyorsa nedenini döner
+func (f *occurrenceFilter) check(date time.Time) (string, string) {
+	date = dateOnly(date)
+	for _, p := range f.pauses {
+		if date.Before(dateOnly(p.PausedAt)) {
+			continue
+		}
+		if p.ResumedAt == nil || date.Before(dateOnly(*p.ResumedAt)) {
+			return occurrencePaused, p.Reason
+		}
+	}
+	month, year := f.cal.periodOf(date)
+	for _, sk := range f.skips {
+		if sk.Month == month && sk.Year == year {
+			return occurrenceSkipped, sk.Reason
+		}
+	}
+	return occurrenceScheduled, ""
+}
+
+// skippedLabel kuru çalıştırmada atlanan tekrarın açıklaması
+func skippedLabel(state, reason string) string {
+	if state == occurrencePaused {
+		return "şablon duraklatıldı: " + reason
+	}
+	return "dönem atlandı: " + reason
+}
+
+// findOwnTemplate kullanıcının kendi oluşturduğu şablonu okur
+func (s *RecurringService) findOwnTemplate(householdID, id, userID uint) (*models.RecurringExpense, error) {
+	var item models.RecurringExpense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	if item.CreatedBy != userID {
+		return nil, errors.New("sadece kendi oluşturduğunuz şablonları değiştirebilirsiniz")
+	}
+	if !item.IsActive {
+		return nil, errors.New("şablon aktif değil")
+	}
+	return &item, nil
+}
+
+func validateReason(reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return &ValidationError{Fields: map[string]string{"reason": "neden belirtilmelidir"}}
+	}
+	if len(reason) > 255 {
+		return &ValidationError{Fields: map[string]string{"reason": "neden en fazla 255 karakter olabilir"}}
+	}
+	return nil
+}
+
+// Pause şablonu bugünden itibaren duraklatır; devam ettirilene kadar tekrar üretilmez.
+// Duraklatılan dönemler sonradan da üretilmez.
+func (s *RecurringService) Pause(householdID, id, userID uint, reason string) error {
+	if err := validateReason(reason); err != nil {
+		return err
+	}
+	item, err := s.findOwnTemplate(householdID, id, userID)
+	if err != nil {
+		return err
+	}
+	if item.PausedAt != nil {
+		return errors.New("şablon zaten duraklatılmış")
+	}
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return err
+	}
+
+	today := cal.today()
+	before := *item
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		pause := models.RecurringPause{
+			RecurringExpenseID: item.ID,
+			PausedAt:           today,
+			PausedBy:           userID,
+			Reason:             strings.TrimSpace(reason),
+		}
+		if err := tx.Create(&pause).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(item).Update("paused_at", today).Error; err != nil {
+			return err
+		}
+		after := before
+		after.PausedAt = &today
+		return recordAudit(tx, householdID, userID, auditRecurring, item.ID, models.AuditPause, &before, &after)
+	})
+}
+
+// Resume duraklatılmış şablonu bugünden itibaren devam ettirir
+func (s *RecurringService) Resume(householdID, id, userID uint, reason string) error {
+	if err := validateReason(reason); err != nil {
+		return err
+	}
+	item, err := s.findOwnTemplate(householdID, id, userID)
+	if err != nil {
+		return err
+	}
+	if item.PausedAt == nil {
+		return errors.New("şablon duraklatılmamış")
+	}
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return err
+	}
+
+	today := cal.today()
+	before := *item
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RecurringPause{}).
+			Where("recurring_expense_id = ? AND resumed_at IS NULL", item.ID).
+			Updates(map[string]interface{}{
+				"resumed_at":    today,
+				"resumed_by":    userID,
+				"resume_reason": strings.TrimSpace(reason),
+			}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(item).Update("paused_at", nil).Error; err != nil {
+			return err
+		}
+		after := before
+		after.PausedAt = nil
+		return recordAudit(tx, householdID, userID, auditRecurring, item.ID, models.AuditResume, &before, &after)
+	})
+}
+
+// Pauses şablonun duraklatma geçmişi
+func (s *RecurringService) Pauses(householdID, id uint) ([]models.RecurringPause, error) {
+	var item models.RecurringExpense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	var pauses []models.RecurringPause
+	err := s.db.Preload("Pauser").Preload("Resumer").
+		Where("recurring_expense_id = ?", item.ID).
+		Order("paused_at DESC, id DESC").
+		Find(&pauses).Error
+	return pauses, err
+}
+
+// Skips şablonun atlanan dönemleri
+func (s *RecurringService) Skips(householdID, id uint) ([]models.RecurringSkip, error) {
+	var item models.RecurringExpense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	var skips []models.RecurringSkip
+	err := s.db.Preload("Creator").
+		Where("recurring_expense_id = ?", item.ID).
+		Order("skip_year ASC, skip_month ASC").
+		Find(&skips).Error
+	return skips, err
+}
+
+// AddSkip şablonun verilen dönemdeki tekrarlarını atlar. Gideri zaten oluşturulmuş dönem atlanamaz.
+func (s *RecurringService) AddSkip(householdID, id, userID uint, month, year int, reason string) (*models.RecurringSkip, error) {
+	if err := validPeriod(month, year); err != nil {
+		return nil, err
+	}
+	if err := validateReason(reason); err != nil {
+		return nil, err
+	}
+	item, err := s.findOwnTemplate(householdID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var generated int64
+	err = s.db.Model(&models.Expense{}).Scopes(inPeriod(month, year)).
+		Where("recurring_expense_id = ?", item.ID).
+		Count(&generated).Error
+	if err != nil {
+		return nil, err
+	}
+	if generated > 0 {
+		return nil, errors.New("bu dönem için gider zaten oluşturulmuş")
+	}
+
+	var exists int64
+	err = s.db.Model(&models.RecurringSkip{}).
+		Where("recurring_expense_id = ? AND skip_month = ? AND skip_year = ?", item.ID, month, year).
+		Count(&exists).Error
+	if err != nil {
+		return nil, err
+	}
+	if exists > 0 {
+		return nil, errors.New("bu dönem zaten atlanıyor")
+	}
+
+	skip := models.RecurringSkip{
+		RecurringExpenseID: item.ID,
+		Month:              month,
+		Year:               year,
+		Reason:             strings.TrimSpace(reason),
+		CreatedBy:          userID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&skip).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditRecurringSkip, skip.ID, models.AuditCreate, nil, &skip)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &skip, nil
+}
+
+// DeleteSkip atlanan dönemi geri alır; dönem tekrar üretilebilir hâle gelir
+func (s *RecurringService) DeleteSkip(householdID, id, skipID, userID uint) error {
+	item, err := s.findOwnTemplate(householdID, id, userID)
+	if err != nil {
+		return err
+	}
+	var skip models.RecurringSkip
+	if err := s.db.Where("recurring_expense_id = ?", item.ID).First(&skip, skipID).Error; err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&skip).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditRecurringSkip, skip.ID, models.AuditDelete, &skip, nil)
+	})
+}
+
+// Upcoming şablonun sıradaki count tekrarını duraklatılmış ve atlanan dönemlerle birlikte döner
+func (s *RecurringService) Upcoming(householdID, id uint, count int) ([]UpcomingOccurrence, error) {
+	if count < 1 || count > maxUpcoming {
+		count = 6
+	}
+	var item models.RecurringExpense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	upcoming := []UpcomingOccurrence{}
+	if !item.IsActive || item.Status == models.StatusRejected || item.PayoffExpenseID != nil {
+		return upcoming, nil
+	}
+
+	filter, err := loadOccurrenceFilter(s.db, &item)
+	if err != nil {
+		return nil, err
+	}
+	amount := item.Amount
+	if item.Type == models.TypeVariable {
+		if amount, err = estimateAmount(s.db, &item); err != nil {
+			return nil, err
+		}
+	}
+
+	// Taksitlerde kalan sayıdan fazlası planlanmaz
+	remaining := -1
+	if item.Type == models.TypeInstallment && item.InstallmentsRemaining != nil {
+		remaining = *item.InstallmentsRemaining
+	}
+	next := paidInstallments(&item) + 1
+
+	from := resumeFrom(&item)
+	if today := filter.cal.today(); from.Before(today) {
+		from = today
+	}
+	for _, date := range upcomingOccurrences(&item, from, count) {
+		if remaining == 0 {
+			break
+		}
+		state, reason := filter.check(date)
+		o := UpcomingOccurrence{Date: date, Amount: amount, State: state, Reason: reason}
+		if state == occurrenceScheduled {
+			if item.Type == models.TypeInstallment {
+				o.Amount = installmentAmount(&item, next)
+				next++
+				remaining--
+			}
+		}
+		upcoming = append(upcoming, o)
+	}
+	return upcoming, nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+const auditRecurringRevision = "recurring_revision"
+
+// recurringRevisionFrom şablonun mevcut değerlerinden bir sürüm üretir
+func recurringRevisionFrom(item *models.RecurringExpense) models.RecurringRevision {
+	return models.RecurringRevision{
+		RecurringExpenseID: item.ID,
+		CategoryID:         item.CategoryID,
+		Description:        item.Description,
+		Amount:             item.Amount,
+		TotalAmount:        item.TotalAmount,
+		PaidBy:             item.PaidBy,
+		IsShared:           item.IsShared,
+		SplitRatio:         item.SplitRatio,
+	}
+}
+
+// apply değişiklikleri sürüme uygular
+func (c RecurringChanges) apply(rev *models.RecurringRevision) {
+	if c.CategoryID != nil {
+		rev.CategoryID = *c.CategoryID
+	}
+	if c.Description != nil {
+		rev.Description = strings.TrimSpace(*c.Description)
+	}
+	if c.Amount != nil {
+		rev.Amount = *c.Amount
+	}
+	if c.TotalAmount != nil {
+		total := *c.TotalAmount
+		rev.TotalAmount = &total
+	}
+	if c.PaidBy != nil {
+		rev.PaidBy = *c.PaidBy
+	}
+	if c.IsShared != nil {
+		rev.IsShared = *c.IsShared
+	}
+	if c.SplitRatio != nil {
+		rev.SplitRatio = *c.SplitRatio
+	}
+}
+
+// nextRecurringVersion şablon için sıradaki sürüm numarasını döner
+func nextRecurringVersion(tx *gorm.DB, itemID uint) (int, error) {
+	var last int
+	err := tx.Model(&models.RecurringRevision{}).
+		Select("COALESCE(MAX(version), 0)").
+		Where("recurring_expense_id = ?", itemID).
+		Scan(&last).Error
+	return last + 1, err
+}
+
+// proposeRevision onaylı şablon için bekleyen yeni sürüm oluşturur
+func (s *RecurringService) proposeRevision(item *models.RecurringExpense, userID uint, changes RecurringChanges) (*models.RecurringRevision, error) {
+	rev := recurringRevisionFrom(item)
+	changes.apply(&rev)
+	return s.saveProposal(item, userID, &rev)
+}
+
+// saveProposal sürümü onay bekleyen olarak kaydeder; şablonun aynı anda tek bekleyen sürümü olabilir
+func (s *RecurringService) saveProposal(item *models.RecurringExpense, userID uint, rev *models.RecurringRevision) (*models.RecurringRevision, error) {
+	rev.ProposedBy = userID
+	rev.Status = models.StatusPending
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var pending int64
+		err := tx.Model(&models.RecurringRevision{}).
+			Where("recurring_expense_id = ? AND status = ?", item.ID, models.StatusPending).
+			Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return errors.New("bu şablon için zaten onay bekleyen bir değişiklik var")
+		}
+		if rev.Version, err = nextRecurringVersion(tx, item.ID); err != nil {
+			return err
+		}
+		if err := tx.Create(rev).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, item.HouseholdID, userID, auditRecurringRevision, rev.ID, models.AuditCreate, nil, rev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+// findPendingRecurringRevision şablona ait bekleyen sürümü okur
+func (s *RecurringService) findPendingRecurringRevision(householdID, itemID, revisionID uint) (*models.RecurringExpense, *models.RecurringRevision, error) {
+	var item models.RecurringExpense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, itemID).Error; err != nil {
+		return nil, nil, err
+	}
+	if item.Status != models.StatusApproved {
+		return nil, nil, errors.New("onaylanmamış şablonun sürümleri şablonla birlikte onaylanır")
+	}
+	var rev models.RecurringRevision
+	if err := s.db.Where("recurring_expense_id = ?", item.ID).First(&rev, revisionID).Error; err != nil {
+		return nil, nil, err
+	}
+	if rev.Status != models.StatusPending {
+		return nil, nil, errors.New("bu değişiklik zaten işlenmiş")
+	}
+	return &item, &rev, nil
+}
+
+// ApproveRevision bekleyen sürümü şablona uygular; bundan sonra üretilen giderler bu sürümden gelir
+func (s *RecurringService) ApproveRevision(householdID, itemID, revisionID, userID uint, isAdmin bool) error {
+	item, rev, err := s.findPendingRecurringRevision(householdID, itemID, revisionID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && rev.ProposedBy == userID {
+		return errors.New("kendi değişikliğinizi onaylayamazsınız")
+	}
+
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(forUpdate).First(item, item.ID).Error; err != nil {
+			return err
+		}
+		// Öneriden bu yana kategori silinmiş ya da ödeyen haneden ayrılmış olabilir
+		v := &ValidationError{}
+		if err := validateCategory(tx, v, rev.CategoryID); err != nil {
+			return err
+		}
+		if err := validatePayer(tx, v, householdID, rev.PaidBy); err != nil {
+			return err
+		}
+		if err := v.result(); err != nil {
+			return err
+		}
+
+		before := *item
+		updates := map[string]interface{}{
+			"category_id":         rev.CategoryID,
+			"description":         rev.Description,
+			"amount":              rev.Amount,
+			"total_amount":        rev.TotalAmount,
+			"paid_by":             rev.PaidBy,
+			"is_shared":           rev.IsShared,
+			"split_ratio":         rev.SplitRatio,
+			"current_revision_id": rev.ID,
+		}
+		reviewed := map[string]interface{}{
+			"status":      models.StatusApproved,
+			"reviewed_by": userID,
+			"reviewed_at": now,
+		}
+
+		// Yeniden yapılandırma, öneriden bu yana üretilen taksitler düşülerek onay anındaki
+		// kalan borç üzerinden uygulanır; sürüm de uygulanan tutarları taşır
+		if rev.InstallmentsRemaining != nil {
+			if !item.IsActive || item.PayoffExpenseID != nil || item.InstallmentsRemaining == nil || *item.InstallmentsRemaining <= 0 {
+				return errors.New("taksit planı kapanmış; yeniden yapılandırma uygulanamaz")
+			}
+			rate := item.InterestRate
+			if rev.InterestRate != nil {
+				rate = *rev.InterestRate
+			}
+			plan, err := restructurePlan(tx, item, *rev.InstallmentsRemaining, rate)
+			if err != nil {
+				return err
+			}
+			for k, v := range plan {
+				updates[k] = v
+			}
+			reviewed["amount"] = item.Amount
+			reviewed["total_amount"] = item.TotalAmount
+			reviewed["principal"] = item.Principal
+		}
+
+		if err := tx.Model(item).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(rev).Updates(reviewed).Error; err != nil {
+			return err
+		}
+
+		var after models.RecurringExpense
+		if err := tx.First(&after, item.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, householdID, userID, auditRecurring, item.ID, models.AuditUpdate, &before, &after)
+	})
+}
+
+// RejectRevision bekleyen sürümü reddeder; öneren de kendi değişikliğini geri çekebilir
+func (s *RecurringService) RejectRevision(householdID, itemID, revisionID, userID uint) error {
+	_, rev, err := s.findPendingRecurringRevision(householdID, itemID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	before := *rev
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(rev).Updates(map[string]interface{}{
+			"status":      models.StatusRejected,
+			"reviewed_by": userID,
+			"reviewed_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		after := before
+		after.Status = models.StatusRejected
+		after.ReviewedBy = &userID
+		after.ReviewedAt = &now
+		return recordAudit(tx, householdID, userID, auditRecurringRevision, rev.ID, models.AuditReject, &before, &after)
+	})
+}
+
+// Revisions şablonun tüm sürümlerini eskiden yeniye döner
+func (s *RecurringService) Revisions(householdID, itemID uint) ([]models.RecurringRevision, error) {
+	var item models.RecurringExpense
+	if err := s.db.Scopes(inHousehold(householdID)).First(&item, itemID).Error; err != nil {
+		return nil, err
+	}
+	var revisions []models.RecurringRevision
+	err := s.db.Preload("Proposer").Preload("Reviewer").
+		Where("recurring_expense_id = ?", item.ID).
+		Order("version ASC").
+		Find(&revisions).Error
+	return revisions, err
+}
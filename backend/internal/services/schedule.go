@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+)
+
+// dateOnly saat bilgisini atar; vade tarihleri gün olarak karşılaştırılır
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// monthStart ayın ilk günü
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// clampedDate ayda olmayan günleri ayın son gününe çeker (31 Şubat → 28/29 Şubat)
+func clampedDate(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// validateSchedule şablonun tekrar tanımını doğrular
+func validateSchedule(item *models.RecurringExpense) error {
+	switch item.Frequency {
+	case models.FrequencyWeekly, models.FrequencyMonthly, models.FrequencyYearly:
+	default:
+		return errors.New("geçersiz tekrar sıklığı")
+	}
+	if item.Interval < 1 {
+		return errors.New("tekrar aralığı en az 1 olmalı")
+	}
+	if item.DayOfMonth != nil && (*item.DayOfMonth < 1 || *item.DayOfMonth > 31) {
+		return errors.New("ayın günü 1 ile 31 arasında olmalı")
+	}
+	if item.EndDate != nil && item.EndDate.Before(item.StartDate) {
+		return errors.New("bitiş tarihi başlangıçtan önce olamaz")
+	}
+	return nil
+}
+
+// occurrence şablonun k. tekrarının vade tarihi. Haftalık tekrarlar başlangıç gününün
+// haftanın gününe, aylık ve yıllık tekrarlar DayOfMonth'a (yoksa başlangıç gününe) düşer.
+func occurrence(item *models.RecurringExpense, k int) time.Time {
+	start := dateOnly(item.StartDate)
+	interval := item.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	day := start.Day()
+	if item.DayOfMonth != nil {
+		day = *item.DayOfMonth
+	}
+
+	switch item.Frequency {
+	case models.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*interval*k)
+	case models.FrequencyYearly:
+		return clampedDate(start.Year()+interval*k, start.Month(), day)
+	default:
+		months := int(start.Month()) - 1 + interval*k
+		return clampedDate(start.Year()+months/12, time.Month(months%12+1), day)
+	}
+}
+
+// dueOccurrences şablonun [from, to] aralığına düşen vade tarihlerini sırayla döner.
+// Başlangıçtan önceki ve bitişten sonraki tarihler dahil edilmez.
+func dueOccurrences(item *models.RecurringExpense, from, to time.Time) []time.Time {
+	from, to = dateOnly(from), dateOnly(to)
+	start := dateOnly(item.StartDate)
+	if item.EndDate != nil && dateOnly(*item.EndDate).Before(to) {
+		to = dateOnly(*item.EndDate)
+	}
+
+	var dates []time.Time
+	for k := 0; ; k++ {
+		date := occurrence(item, k)
+		if date.After(to) {
+			break
+		}
+		if date.Before(from) || date.Before(start) {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+// upcomingOccurrences şablonun from tarihinden itibaren en fazla n vade tarihini döner.
+// Bitiş tarihi varsa daha az tarih dönebilir.
+func upcomingOccurrences(item *models.RecurringExpense, from time.Time, n int) []time.Time {
+	from = dateOnly(from)
+	start := dateOnly(item.StartDate)
+
+	var dates []time.Time
+	for k := 0; len(dates) < n; k++ {
+		date := occurrence(item, k)
+		if item.EndDate != nil && date.After(dateOnly(*item.EndDate)) {
+			break
+		}
+		if date.Before(from) || date.Before(start) {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	return dates
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caner/home-gider/internal/models"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func datePtr(t time.Time) *time.Time { return &t }
+
+func intPtr(i int) *int { return &i }
+
+func sameDates(t *testing.T, name string, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: %d tarih %v, beklenen %v", name, len(got), got, want)
+		return
+	}
+	for i := range got {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("%s: %d. tarih %s, beklenen %s", name, i, got[i].Format("2006-01-02"), want[i].Format("2006-01-02"))
+		}
+	}
+}
+
+func TestOccurrence(t *testing.T) {
+	tests := []struct {
+		name string
+		item models.RecurringExpense
+		k    int
+		want time.Time
+	}{
+		{"aylık 31 şubatta son güne çekilir",
+			models.RecurringExpense{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: day(2025, 1, 31)}, 1, day(2025, 2, 28)},
+		{"artık yılda 29 şubat",
+			models.RecurringExpense{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: day(2024, 1, 31)}, 1, day(2024, 2, 29)},
+		{"şubattan sonra 31'e döner",
+			models.RecurringExpense{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: day(2025, 1, 31)}, 2, day(2025, 3, 31)},
+		{"DayOfMonth başlangıç gününe baskın",
+			models.RecurringExpense{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: day(2025, 1, 10), DayOfMonth: intPtr(31)}, 3, day(2025, 4, 30)},
+		{"üç ayda bir yıl atlar",
+			models.RecurringExpense{Frequency: models.FrequencyMonthly, Interval: 3, StartDate: day(2025, 11, 15)}, 1, day(2026, 2, 15)},
+		{"iki haftada bir",
+			models.RecurringExpense{Frequency: models.FrequencyWeekly, Interval: 2, StartDate: day(2025, 12, 25)}, 2, day(2026, 1, 22)},
+		{"yıllık 29 şubat",
+			models.RecurringExpense{Frequency: models.FrequencyYearly, Interval: 1, StartDate: day(2024, 2, 29)}, 1, day(2025, 2, 28)},
+		{"sıfır aralık 1 sayılır",
+			models.RecurringExpense{Frequency: models.FrequencyMonthly, StartDate: day(2025, 5, 5)}, 1, day(2025, 6, 5)},
+	}
+	for _, tt := range tests {
+		if got := occurrence(&tt.item, tt.k); !got.Equal(tt.want) {
+			t.Errorf("%s: %s, beklenen %s", tt.name, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestDueOccurrences(t *testing.T) {
+	monthly := models.RecurringExpense{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: day(2025, 1, 31)}
+	sameDates(t, "aylık", dueOccurrences(&monthly, day(2025, 1, 1), day(2025, 4, 30)),
+		[]time.Time{day(2025, 1, 31), day(2025, 2, 28), day(2025, 3, 31), day(2025, 4, 30)})
+
+	sameDates(t, "başlangıçtan öncesi yok", dueOccurrences(&monthly, day(2024, 10, 1), day(2025, 2, 1)),
+		[]time.Time{day(2025, 1, 31)})
+
+	ended := monthly
+	ended.EndDate = datePtr(day(2025, 3, 30))
+	sameDates(t, "bitiş tarihinden sonrası yok", dueOccurrences(&ended, day(2025, 1, 1), day(2025, 12, 31)),
+		[]time.Time{day(2025, 1, 31), day(2025, 2, 28)})
+
+	weekly := models.RecurringExpense{Frequency: models.FrequencyWeekly, Interval: 1, StartDate: day(2025, 3, 3)}
+	sameDates(t, "haftalık aynı ayda birden fazla", dueOccurrences(&weekly, day(2025, 3, 1), day(2025, 3, 20)),
+		[]time.Time{day(2025, 3, 3), day(2025, 3, 10), day(2025, 3, 17)})
+
+	sameDates(t, "ters aralık", dueOccurrences(&weekly, day(2025, 4, 1), day(2025, 3, 1)), nil)
+}
+
+// Son üretilen vadeden sonraki gün kaldığı yerden devam edilir; aynı vade iki kez üretilmez,
+// sunucunun kapalı kaldığı aylar da atlanmaz
+func TestDueOccurrencesCatchUp(t *testing.T) {
+	item := models.RecurringExpense{
+		Frequency:           models.FrequencyMonthly,
+		Interval:            1,
+		StartDate:           day(2025, 1, 31),
+		LastGeneratedPeriod: datePtr(day(2025, 2, 28)),
+	}
+	sameDates(t, "kaldığı yerden", dueOccurrences(&item, resumeFrom(&item), day(2025, 5, 15)),
+		[]time.Time{day(2025, 3, 31), day(2025, 4, 30)})
+
+	item.LastGeneratedPeriod = nil
+	sameDates(t, "hiç üretilmemiş", dueOccurrences(&item, resumeFrom(&item), day(2025, 3, 1)),
+		[]time.Time{day(2025, 1, 31), day(2025, 2, 28)})
+
+	item.LastGeneratedPeriod = datePtr(day(2025, 4, 30))
+	sameDates(t, "güncel", dueOccurrences(&item, resumeFrom(&item), day(2025, 5, 15)), nil)
+}
+
+func TestUpcomingOccurrences(t *testing.T) {
+	item := models.RecurringExpense{
+		Frequency: models.FrequencyMonthly,
+		Interval:  2,
+		StartDate: day(2025, 1, 15),
+		EndDate:   datePtr(day(2025, 9, 1)),
+	}
+	sameDates(t, "bitişe kadar", upcomingOccurrences(&item, day(2025, 2, 1), 10),
+		[]time.Time{day(2025, 3, 15), day(2025, 5, 15), day(2025, 7, 15)})
+	sameDates(t, "en fazla n", upcomingOccurrences(&item, day(2025, 1, 1), 2),
+		[]time.Time{day(2025, 1, 15), day(2025, 3, 15)})
+}
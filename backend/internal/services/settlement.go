@@ -2,7 +2,8 @@ package services
 
 import (
 	"errors"
-	"math"
+	"sort"
+	"time"
 
 	"github.com/caner/home-gider/internal/models"
 	"gorm.io/gorm"
@@ -17,50 +18,76 @@ func NewSettlementService(db *gorm.DB) *SettlementService {
 }
 
 type UserSummary struct {
-	UserID      uint    `json:"user_id"`
-	DisplayName string  `json:"display_name"`
-	TotalPaid   float64 `json:"total_paid"`
-	TotalShare  float64 `json:"total_share"`
-	Balance     float64 `json:"balance"`
+	UserID           uint         `json:"user_id"`
+	DisplayName      string       `json:"display_name"`
+	TotalPaid        models.Money `json:"total_paid"`
+	TotalShare       models.Money `json:"total_share"`
+	Balance          models.Money `json:"balance"`
+	PaymentsMade     models.Money `json:"payments_made"`
+	PaymentsReceived models.Money `json:"payments_received"`
+	NetBalance       models.Money `json:"net_balance"`
+	// OpeningBalance önceki aylardan devreden, ClosingBalance bu ay sonundaki kümülatif bakiye
+	OpeningBalance models.Money `json:"opening_balance"`
+	ClosingBalance models.Money `json:"closing_balance"`
+}
+
+// Transfer borçları kapatmak için gereken tek bir "A, B'ye X ödesin" adımı
+type Transfer struct {
+	FromID   uint         `json:"from_id"`
+	FromName string       `json:"from_name"`
+	ToID     uint         `json:"to_id"`
+	ToName   string       `json:"to_name"`
+	Amount   models.Money `json:"amount"`
 }
 
 type MonthlySummary struct {
-	Month             int           `json:"month"`
-	Year              int           `json:"year"`
-	TotalExpenses     float64       `json:"total_expenses"`
-	SharedExpenses    float64       `json:"shared_expenses"`
-	UserSummaries     []UserSummary `json:"user_summaries"`
-	DebtorID          *uint         `json:"debtor_id"`
-	CreditorID        *uint         `json:"creditor_id"`
-	DebtAmount        float64       `json:"debt_amount"`
-	TotalPayments     float64       `json:"total_payments"`
-	RemainingDebt     float64       `json:"remaining_debt"`
-	CategoryBreakdown []CategorySum `json:"category_breakdown"`
+	Month          int           `json:"month"`
+	Year           int           `json:"year"`
+	TotalExpenses  models.Money  `json:"total_expenses"`
+	SharedExpenses models.Money  `json:"shared_expenses"`
+	UserSummaries  []UserSummary `json:"user_summaries"`
+	// Transfers devreden bakiye ve ödemeler dahil kalan borçları kapatan en az sayıda transfer
+	Transfers []Transfer `json:"transfers"`
+	// DebtorID/CreditorID yalnızca tek bir borç ilişkisi varsa doldurulur (iki kişilik ev)
+	DebtorID          *uint               `json:"debtor_id"`
+	CreditorID        *uint               `json:"creditor_id"`
+	DebtAmount        models.Money        `json:"debt_amount"`
+	TotalPayments     models.Money        `json:"total_payments"`
+	RemainingDebt     models.Money        `json:"remaining_debt"`
+	CategoryBreakdown []CategorySum       `json:"category_breakdown"`
+	PeriodStatus      models.PeriodStatus `json:"period_status"`
 }
 
 type CategorySum struct {
-	CategoryID   uint    `json:"category_id"`
-	CategoryName string  `json:"category_name"`
-	CategoryIcon string  `json:"category_icon"`
-	Total        float64 `json:"total"`
+	CategoryID   uint         `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	CategoryIcon string       `json:"category_icon"`
+	Total        models.Money `json:"total"`
 }
 
-func (s *SettlementService) GetMonthlySummary(month, year int, sharedOnly bool) (*MonthlySummary, error) {
+func (s *SettlementService) GetMonthlySummary(householdID uint, month, year int, sharedOnly bool) (*MonthlySummary, error) {
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
+	month, year = cal.resolve(month, year)
+
 	var expenses []models.Expense
-	query := s.db.Preload("Creator").Preload("Category").
-		Where("expense_month = ? AND expense_year = ? AND status = ?",
-			month, year, models.StatusApproved)
+	query := s.db.Scopes(inHousehold(householdID), inPeriod(month, year)).Preload("Creator").Preload("Category").Preload("Splits").
+		Where("status = ?", models.StatusApproved)
 	if sharedOnly {
 		query = query.Where("is_shared = ?", true)
 	}
-	err := query.Find(&expenses).Error
+	err = query.Find(&expenses).Error
 	if err != nil {
 		return nil, err
 	}
 
-	// Sadece admin olmayan kullanıcıları al (hesaplamaya admin dahil değil)
-	var users []models.User
-	s.db.Where("is_admin = ?", false).Find(&users)
+	// Sadece hanenin admin olmayan üyelerini al (hesaplamaya admin dahil değil)
+	users, err := householdMembers(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
 
 	userMap := make(map[uint]*UserSummary)
 	for _, u := range users {
@@ -70,7 +97,7 @@ func (s *SettlementService) GetMonthlySummary(month, year int, sharedOnly bool)
 		}
 	}
 
-	var totalExpenses, sharedExpenses float64
+	var totalExpenses, sharedExpenses models.Money
 	categoryMap := make(map[uint]*CategorySum)
 
 	for _, e := range expenses {
@@ -85,76 +112,96 @@ func (s *SettlementService) GetMonthlySummary(month, year int, sharedOnly bool)
 		}
 		categoryMap[e.CategoryID].Total += e.Amount
 
-		if summary, ok := userMap[e.CreatedBy]; ok {
+		// Gideri kim girdiyse değil, kim ödediyse onun hanesine yazılır
+		if summary, ok := userMap[e.PaidBy]; ok {
 			summary.TotalPaid += e.Amount
 		}
 
-		if !e.IsShared {
-			if summary, ok := userMap[e.CreatedBy]; ok {
-				summary.TotalShare += e.Amount
-			}
-			continue
+		if e.IsShared {
+			sharedExpenses += e.Amount
 		}
 
-		sharedExpenses += e.Amount
-
-		creatorShare := e.Amount * e.SplitRatio / 100
-		otherShare := e.Amount - creatorShare
-
-		if summary, ok := userMap[e.CreatedBy]; ok {
-			summary.TotalShare += creatorShare
+		// Her katılımcının payı expense_splits tablosundan okunur
+		for _, sp := range e.Splits {
+			if summary, ok := userMap[sp.UserID]; ok {
+				summary.TotalShare += sp.Amount
+			}
 		}
+	}
 
-		for uid, summary := range userMap {
-			if uid != e.CreatedBy {
-				summary.TotalShare += otherShare
-			}
+	// Yapılan ödemeleri hesapla; yalnızca alacaklının onayladığı ödemeler sayılır
+	var payments []models.Payment
+	err = s.db.Scopes(inHousehold(householdID)).
+		Where("month = ? AND year = ? AND status = ?", month, year, models.StatusApproved).
+		Find(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+	var totalPayments models.Money
+	for _, p := range payments {
+		totalPayments += p.Amount
+		if summary, ok := userMap[p.PayerID]; ok {
+			summary.PaymentsMade += p.Amount
+		}
+		if summary, ok := userMap[p.PayeeID]; ok {
+			summary.PaymentsReceived += p.Amount
 		}
 	}
 
-	summaries := make([]UserSummary, 0, len(userMap))
-	for _, summary := range userMap {
-		summary.Balance = math.Round((summary.TotalPaid-summary.TotalShare)*100) / 100
+	// Önceki aylardan devreden bakiye
+	opening, err := s.ledgerTotals(householdID, periodKey(month, year))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]UserSummary, 0, len(users))
+	for _, u := range users {
+		summary := userMap[u.ID]
+		summary.Balance = summary.TotalPaid - summary.TotalShare
+		summary.NetBalance = summary.Balance + summary.PaymentsMade - summary.PaymentsReceived
+		if o, ok := opening[u.ID]; ok {
+			summary.OpeningBalance = o.net()
+		}
+		summary.ClosingBalance = summary.OpeningBalance + summary.NetBalance
 		summaries = append(summaries, *summary)
 	}
 
 	result := &MonthlySummary{
 		Month:          month,
 		Year:           year,
-		TotalExpenses:  math.Round(totalExpenses*100) / 100,
-		SharedExpenses: math.Round(sharedExpenses*100) / 100,
+		TotalExpenses:  totalExpenses,
+		SharedExpenses: sharedExpenses,
 		UserSummaries:  summaries,
+		TotalPayments:  totalPayments,
 	}
 
-	// Kim kime borçlu?
-	if len(summaries) == 2 {
-		if summaries[0].Balance > 0 {
-			result.CreditorID = &summaries[0].UserID
-			result.DebtorID = &summaries[1].UserID
-			result.DebtAmount = math.Round(summaries[0].Balance*100) / 100
-		} else if summaries[1].Balance > 0 {
-			result.CreditorID = &summaries[1].UserID
-			result.DebtorID = &summaries[0].UserID
-			result.DebtAmount = math.Round(summaries[1].Balance*100) / 100
-		}
+	// Kim kime borçlu? Devreden bakiye dahil, bu ayın ödemeleri öncesi ve sonrası için ayrı ayrı sadeleştir
+	debts := simplifyDebts(summaries, func(u UserSummary) models.Money { return u.OpeningBalance + u.Balance })
+	for _, t := range debts {
+		result.DebtAmount += t.Amount
+	}
+	if len(debts) == 1 {
+		result.DebtorID = &debts[0].FromID
+		result.CreditorID = &debts[0].ToID
 	}
 
-	// Yapılan ödemeleri hesapla
-	var payments []models.Payment
-	s.db.Where("month = ? AND year = ?", month, year).Find(&payments)
-	var totalPayments float64
-	for _, p := range payments {
-		totalPayments += p.Amount
+	result.Transfers = simplifyDebts(summaries, func(u UserSummary) models.Money { return u.ClosingBalance })
+	for _, t := range result.Transfers {
+		result.RemainingDebt += t.Amount
+	}
+
+	result.PeriodStatus = models.PeriodOpen
+	var lock models.PeriodLock
+	err = s.db.Scopes(inHousehold(householdID)).Where("month = ? AND year = ?", month, year).Limit(1).Find(&lock).Error
+	if err != nil {
+		return nil, err
 	}
-	result.TotalPayments = math.Round(totalPayments*100) / 100
-	result.RemainingDebt = math.Round((result.DebtAmount-totalPayments)*100) / 100
-	if result.RemainingDebt < 0 {
-		result.RemainingDebt = 0
+	if lock.ID != 0 {
+		result.PeriodStatus = lock.Status
 	}
 
 	categories := make([]CategorySum, 0, len(categoryMap))
 	for _, cs := range categoryMap {
-		cs.Total = math.Round(cs.Total*100) / 100
 		categories = append(categories, *cs)
 	}
 	result.CategoryBreakdown = categories
@@ -162,38 +209,276 @@ func (s *SettlementService) GetMonthlySummary(month, year int, sharedOnly bool)
 	return result, nil
 }
 
-func (s *SettlementService) GetPayments(month, year int) ([]models.Payment, error) {
+// simplifyDebts bakiyeleri sıfırlayan en az sayıda transferi açgözlü yöntemle bulur:
+// her adımda en çok borçlu olan, en çok alacaklı olana ödeme yapar. n kişi için
+// en fazla n-1 transfer üretilir.
+func simplifyDebts(summaries []UserSummary, balance func(UserSummary) models.Money) []Transfer {
+	type party struct {
+		id     uint
+		name   string
+		amount models.Money
+	}
+
+	var creditors, debtors []party
+	for _, u := range summaries {
+		b := balance(u)
+		switch {
+		case b > 0:
+			creditors = append(creditors, party{u.UserID, u.DisplayName, b})
+		case b < 0:
+			debtors = append(debtors, party{u.UserID, u.DisplayName, -b})
+		}
+	}
+	byAmount := func(parties []party) func(i, j int) bool {
+		return func(i, j int) bool {
+			if parties[i].amount != parties[j].amount {
+				return parties[i].amount > parties[j].amount
+			}
+			return parties[i].id < parties[j].id
+		}
+	}
+	sort.Slice(creditors, byAmount(creditors))
+	sort.Slice(debtors, byAmount(debtors))
+
+	transfers := []Transfer{}
+	i, j := 0, 0
+	for i < len(debtors) && j < len(creditors) {
+		amount := min(debtors[i].amount, creditors[j].amount)
+		transfers = append(transfers, Transfer{
+			FromID:   debtors[i].id,
+			FromName: debtors[i].name,
+			ToID:     creditors[j].id,
+			ToName:   creditors[j].name,
+			Amount:   amount,
+		})
+		debtors[i].amount -= amount
+		creditors[j].amount -= amount
+		if debtors[i].amount == 0 {
+			i++
+		}
+		if creditors[j].amount == 0 {
+			j++
+		}
+	}
+	return transfers
+}
+
+func (s *SettlementService) GetPayments(householdID uint, month, year int) ([]models.Payment, error) {
+	cal, err := householdCalendar(s.db, householdID)
+	if err != nil {
+		return nil, err
+	}
+	month, year = cal.resolve(month, year)
+
 	var payments []models.Payment
-	err := s.db.Preload("Payer").Preload("Payee").
+	err = s.db.Scopes(inHousehold(householdID)).Preload("Payer").Preload("Payee").Preload("DeleteRequester").
 		Where("month = ? AND year = ?", month, year).
 		Order("created_at DESC").
 		Find(&payments).Error
 	return payments, err
 }
 
-func (s *SettlementService) AddPayment(month, year int, payerID, payeeID uint, amount float64) error {
-	// Borç miktarını hesapla
-	summary, err := s.GetMonthlySummary(month, year, true)
+func (s *SettlementService) AddPayment(householdID uint, month, year int, payerID, payeeID uint, amount models.Money) error {
+	if payerID == payeeID {
+		return errors.New("kendinize ödeme yapamazsınız")
+	}
+	// Bu çift için kalan borcu bul (önceki aylardan devreden dahil)
+	summary, err := s.GetMonthlySummary(householdID, month, year, false)
 	if err != nil {
 		return err
 	}
-	if summary.RemainingDebt <= 0 {
-		return errors.New("bu ay için borç kalmamış")
+	transfer := findTransfer(summary.Transfers, payerID, payeeID)
+	if transfer == nil {
+		return errors.New("bu ay için bu kişiye borcunuz yok")
 	}
-	if amount > summary.RemainingDebt {
-		return errors.New("ödeme tutarı kalan borçtan büyük olamaz")
+	if err := s.checkPaymentAmount(householdID, month, year, payerID, payeeID, amount, transfer.Amount); err != nil {
+		return err
 	}
 
 	payment := models.Payment{
-		Month:   month,
-		Year:    year,
-		PayerID: payerID,
-		PayeeID: payeeID,
-		Amount:  amount,
+		HouseholdID: householdID,
+		Month:       month,
+		Year:        year,
+		PayerID:     payerID,
+		PayeeID:     payeeID,
+		Amount:      amount,
+		Status:      models.StatusPending,
+	}
+	return s.createPayment(&payment)
+}
+
+// createPayment ödemeyi audit kaydıyla birlikte yazar. Ödemenin ayı yazma anında kilitlenerek
+// açık olduğu doğrulanır; ödeme okuyan diğer yardımcılar da aynı kontrolü yapar.
+func (s *SettlementService) createPayment(payment *models.Payment) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, payment.HouseholdID, payment.Month, payment.Year); err != nil {
+			return err
+		}
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, payment.HouseholdID, payment.PayerID, auditPayment, payment.ID, models.AuditCreate, nil, payment)
+	})
+}
+
+// updatePayment ödemeyi günceller ve değişikliği aynı transaction'da kaydeder
+func (s *SettlementService) updatePayment(payment *models.Payment, userID uint, action models.AuditAction, updates map[string]interface{}, after models.Payment) error {
+	before := *payment
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, payment.HouseholdID, payment.Month, payment.Year); err != nil {
+			return err
+		}
+		if err := tx.Model(payment).Updates(updates).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, payment.HouseholdID, userID, auditPayment, payment.ID, action, &before, &after)
+	})
+}
+
+// deletePayment ödemeyi siler ve silinen kaydı saklar
+func (s *SettlementService) deletePayment(payment *models.Payment, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensurePeriodOpen(tx, payment.HouseholdID, payment.Month, payment.Year); err != nil {
+			return err
+		}
+		if err := tx.Delete(payment).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, payment.HouseholdID, userID, auditPayment, payment.ID, models.AuditDelete, payment, nil)
+	})
+}
+
+// checkPaymentAmount onay bekleyen ödemeler de düşülerek kalan borcun aşılmadığını doğrular.
+// Dönem verilirse yalnızca o dönemin bekleyen ödemeleri, verilmezse tümü düşülür.
+func (s *SettlementService) checkPaymentAmount(householdID uint, month, year int, payerID, payeeID uint, amount, remaining models.Money) error {
+	query := s.db.Model(&models.Payment{}).Scopes(inHousehold(householdID)).
+		Where("payer_id = ? AND payee_id = ? AND status = ?", payerID, payeeID, models.StatusPending)
+	if month != 0 && year != 0 {
+		query = query.Where("month = ? AND year = ?", month, year)
+	}
+	var pending models.Money
+	err := query.Select("COALESCE(SUM(amount), 0)::BIGINT").Scan(&pending).Error
+	if err != nil {
+		return err
+	}
+	if amount > remaining-pending {
+		if pending > 0 {
+			return errors.New("ödeme tutarı, onay bekleyen ödemeler düşüldükten sonra kalan borçtan büyük olamaz")
+		}
+		return errors.New("ödeme tutarı kalan borçtan büyük olamaz")
+	}
+	return nil
+}
+
+// findPayment ödemeyi okur; ayının açık olduğu yazma sırasında doğrulanır
+func (s *SettlementService) findPayment(householdID, id uint) (*models.Payment, error) {
+	var payment models.Payment
+	if err := s.db.Scopes(inHousehold(householdID)).First(&payment, id).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// ConfirmPayment alacaklı parayı aldığını onaylar; ödeme ancak bundan sonra hesaplaşmaya girer
+func (s *SettlementService) ConfirmPayment(householdID, id, userID uint) error {
+	payment, err := s.findPayment(householdID, id)
+	if err != nil {
+		return err
+	}
+	if payment.PayeeID != userID {
+		return errors.New("ödemeyi sadece alacaklı onaylayabilir")
+	}
+	if payment.Status != models.StatusPending {
+		return errors.New("bu ödeme zaten işlenmiş")
+	}
+	now := time.Now()
+	after := *payment
+	after.Status = models.StatusApproved
+	after.ConfirmedAt = &now
+	return s.updatePayment(payment, userID, models.AuditApprove, map[string]interface{}{
+		"status":       models.StatusApproved,
+		"confirmed_at": now,
+	}, after)
+}
+
+// DisputePayment alacaklı parayı almadığını bildirir
+func (s *SettlementService) DisputePayment(householdID, id, userID uint) error {
+	payment, err := s.findPayment(householdID, id)
+	if err != nil {
+		return err
+	}
+	if payment.PayeeID != userID {
+		return errors.New("ödemeye sadece alacaklı itiraz edebilir")
+	}
+	if payment.Status != models.StatusPending {
+		return errors.New("bu ödeme zaten işlenmiş")
+	}
+	after := *payment
+	after.Status = models.StatusRejected
+	return s.updatePayment(payment, userID, models.AuditReject, map[string]interface{}{
+		"status": models.StatusRejected,
+	}, after)
+}
+
+// DeletePayment onaylanmamış ödemeyi ödeyen doğrudan silebilir. Onaylı ödemede ödeyen
+// silme talebi açar, alacaklı onaylayınca silinir; alacaklının kendisi doğrudan silebilir.
+func (s *SettlementService) DeletePayment(householdID, id, userID uint) (deleted bool, err error) {
+	payment, err := s.findPayment(householdID, id)
+	if err != nil {
+		return false, err
+	}
+	if payment.PayerID != userID && payment.PayeeID != userID {
+		return false, errors.New("sadece ödemenin tarafları silebilir")
+	}
+
+	if payment.Status != models.StatusApproved || payment.PayeeID == userID {
+		return true, s.deletePayment(payment, userID)
+	}
+	if payment.DeleteRequestedBy != nil {
+		return false, errors.New("bu ödeme için zaten silme talebi var")
+	}
+	after := *payment
+	after.DeleteRequestedBy = &userID
+	return false, s.updatePayment(payment, userID, models.AuditDeleteRequest, map[string]interface{}{
+		"delete_requested_by": userID,
+	}, after)
+}
+
+func (s *SettlementService) ConfirmDeletePayment(householdID, id, userID uint) error {
+	payment, err := s.findPayment(householdID, id)
+	if err != nil {
+		return err
+	}
+	if payment.DeleteRequestedBy == nil {
+		return errors.New("bu ödeme için silme talebi yok")
+	}
+	if payment.PayeeID != userID {
+		return errors.New("silme talebini sadece alacaklı onaylayabilir")
+	}
+	return s.deletePayment(payment, userID)
+}
+
+func (s *SettlementService) CancelDeletePayment(householdID, id, userID uint) error {
+	payment, err := s.findPayment(householdID, id)
+	if err != nil {
+		return err
+	}
+	if payment.DeleteRequestedBy == nil {
+		return errors.New("bu ödeme için silme talebi yok")
+	}
+	if payment.PayerID != userID && payment.PayeeID != userID {
+		return errors.New("sadece ödemenin tarafları işlem yapabilir")
 	}
-	return s.db.Create(&payment).Error
+	after := *payment
+	after.DeleteRequestedBy = nil
+	return s.updatePayment(payment, userID, models.AuditDeleteCancel, map[string]interface{}{
+		"delete_requested_by": nil,
+	}, after)
 }
 
-func (s *SettlementService) DeletePayment(id uint) error {
-	return s.db.Delete(&models.Payment{}, id).Error
+func (s *SettlementService) GetPayment(householdID, id uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := s.db.Scopes(inHousehold(householdID)).Preload("Payer").Preload("Payee").Preload("DeleteRequester").
+		First(&payment, id).Error
+	return &payment, err
 }
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/caner/home-gider/internal/models"
+)
+
+// debt from üyesinin to üyesine olan borcu
+type debt struct {
+	from, to uint
+	amount   models.Money
+}
+
+// netSummaries borçlardan üyelerin net bakiyelerini çıkarır; alacaklı pozitif, borçlu negatiftir
+func netSummaries(members int, debts []debt) []UserSummary {
+	result := make([]UserSummary, members)
+	for i := range result {
+		result[i].UserID = uint(i + 1)
+	}
+	for _, d := range debts {
+		result[d.from-1].NetBalance -= d.amount
+		result[d.to-1].NetBalance += d.amount
+	}
+	return result
+}
+
+func TestSimplifyDebts(t *testing.T) {
+	tests := []struct {
+		name      string
+		members   int
+		debts     []debt
+		transfers int
+	}{
+		{"borç yok", 3, nil, 0},
+		{"karşılıklı eşit borç", 2, []debt{{1, 2, 5000}, {2, 1, 5000}}, 0},
+		{"eşit döngü", 3, []debt{{1, 2, 100}, {2, 3, 100}, {3, 1, 100}}, 0},
+		{"eşit olmayan döngü", 3, []debt{{1, 2, 300}, {2, 3, 200}, {3, 1, 100}}, 2},
+		{"iki kişi", 2, []debt{{2, 1, 5000}}, 1},
+		{"zincir tek transfere iner", 3, []debt{{1, 2, 2500}, {2, 3, 2500}}, 1},
+		{"tek alacaklı", 4, []debt{{2, 1, 3000}, {3, 1, 3000}, {4, 1, 3000}}, 3},
+		{"çok üyeli", 6, []debt{
+			{2, 1, 2500}, {4, 3, 3000}, {4, 1, 1000}, {5, 1, 3501}, {6, 5, 700}, {1, 6, 700},
+		}, 4},
+	}
+	for _, tt := range tests {
+		members := netSummaries(tt.members, tt.debts)
+		transfers := simplifyDebts(members, func(u UserSummary) models.Money { return u.NetBalance })
+		if len(transfers) != tt.transfers {
+			t.Errorf("%s: %d transfer, beklenen %d", tt.name, len(transfers), tt.transfers)
+		}
+
+		var total models.Money
+		net := map[uint]models.Money{}
+		for _, u := range members {
+			net[u.UserID] = u.NetBalance
+			total += u.NetBalance
+		}
+		if total != 0 {
+			t.Fatalf("%s: net bakiyelerin toplamı %s", tt.name, total)
+		}
+		for _, tr := range transfers {
+			if tr.Amount <= 0 || tr.FromID == tr.ToID {
+				t.Errorf("%s: geçersiz transfer %+v", tt.name, tr)
+			}
+			net[tr.FromID] += tr.Amount
+			net[tr.ToID] -= tr.Amount
+		}
+		for id, b := range net {
+			if b != 0 {
+				t.Errorf("%s: transferlerden sonra üye %d bakiyesi %s", tt.name, id, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/caner/home-gider/internal/models"
+	"gorm.io/gorm"
+)
+
+// SplitInput bir katılımcının payı. Hangi alanın kullanılacağı bölüşüm moduna bağlıdır:
+// shares modunda Shares, percentage modunda Percentage, exact modunda Amount.
+type SplitInput struct {
+	UserID     uint         `json:"user_id"`
+	Shares     int          `json:"shares"`
+	Percentage float64      `json:"percentage"`
+	Amount     models.Money `json:"amount"`
+}
+
+// SplitSpec giderin nasıl bölüşüleceği. Mode boş ve Ratio verilmişse (0 dahil) eski tek
+// oranlı davranış uygulanır: oluşturan %Ratio öder, kalan diğer üyelere eşit bölünür.
+type SplitSpec struct {
+	Mode   models.SplitMode `json:"split_mode"`
+	Splits []SplitInput     `json:"splits"`
+	Ratio  *float64         `json:"split_ratio"`
+}
+
+// percentBasisPoints yüzdeyi on binde bire çevirir (33.33 → 3333)
+func percentBasisPoints(p float64) int64 {
+	return int64(math.Round(p * 100))
+}
+
+// splitSpecFrom mevcut paylardan aynı tanımı üretir; tutar değişince yeniden bölmek için
+func splitSpecFrom(mode models.SplitMode, splits []models.ExpenseSplit) SplitSpec {
+	spec := SplitSpec{Mode: mode}
+	for _, sp := range splits {
+		input := SplitInput{UserID: sp.UserID, Amount: sp.Amount}
+		if sp.Shares != nil {
+			input.Shares = *sp.Shares
+		}
+		if sp.Percentage != nil {
+			input.Percentage = *sp.Percentage
+		}
+		spec.Splits = append(spec.Splits, input)
+	}
+	return spec
+}
+
+// buildSplits tutarı tanıma göre katılımcılara böler. Paylar her zaman tutarın tamamına eşittir.
+// Kişisel giderlerde tutarın tamamı oluşturana yazılır.
+func buildSplits(db *gorm.DB, householdID, creatorID uint, amount models.Money, isShared bool, spec SplitSpec) (models.SplitMode, []models.ExpenseSplit, error) {
+	if amount <= 0 {
+		return "", nil, errors.New("tutar sıfırdan büyük olmalı")
+	}
+	if !isShared {
+		return models.SplitEqual, []models.ExpenseSplit{{UserID: creatorID, Amount: amount}}, nil
+	}
+
+	members, err := householdMembers(db, householdID)
+	if err != nil {
+		return "", nil, err
+	}
+	memberSet := make(map[uint]bool, len(members))
+	for _, m := range members {
+		memberSet[m.ID] = true
+	}
+
+	mode := spec.Mode
+	inputs := spec.Splits
+	if mode == "" {
+		if spec.Ratio != nil && memberSet[creatorID] {
+			mode = models.SplitPercentage
+			if inputs, err = ratioInputs(members, creatorID, *spec.Ratio); err != nil {
+				return "", nil, err
+			}
+		} else {
+			mode = models.SplitEqual
+		}
+	}
+	if mode == models.SplitEqual && len(inputs) == 0 {
+		for _, m := range members {
+			inputs = append(inputs, SplitInput{UserID: m.ID})
+		}
+	}
+	if len(inputs) == 0 {
+		return "", nil, errors.New("en az bir katılımcı belirtilmeli")
+	}
+
+	seen := make(map[uint]bool, len(inputs))
+	for _, in := range inputs {
+		if !memberSet[in.UserID] {
+			return "", nil, fmt.Errorf("kullanıcı %d bu hanenin üyesi değil", in.UserID)
+		}
+		if seen[in.UserID] {
+			return "", nil, fmt.Errorf("kullanıcı %d birden fazla kez yazılmış", in.UserID)
+		}
+		seen[in.UserID] = true
+	}
+
+	splits := make([]models.ExpenseSplit, len(inputs))
+	weights := make([]int64, len(inputs))
+	switch mode {
+	case models.SplitEqual:
+		for i, in := range inputs {
+			splits[i].UserID = in.UserID
+			weights[i] = 1
+		}
+	case models.SplitShares:
+		for i, in := range inputs {
+			if in.Shares <= 0 {
+				return "", nil, errors.New("pay sayıları sıfırdan büyük olmalı")
+			}
+			shares := in.Shares
+			splits[i].UserID = in.UserID
+			splits[i].Shares = &shares
+			weights[i] = int64(shares)
+		}
+	case models.SplitPercentage:
+		var total int64
+		for i, in := range inputs {
+			bp := percentBasisPoints(in.Percentage)
+			if bp < 0 {
+				return "", nil, errors.New("yüzdeler negatif olamaz")
+			}
+			percentage := float64(bp) / 100
+			splits[i].UserID = in.UserID
+			splits[i].Percentage = &percentage
+			weights[i] = bp
+			total += bp
+		}
+		if total != 10000 {
+			return "", nil, fmt.Errorf("yüzdelerin toplamı 100 olmalı (şu an %.2f)", float64(total)/100)
+		}
+	case models.SplitExact:
+		var total models.Money
+		for i, in := range inputs {
+			if in.Amount < 0 {
+				return "", nil, errors.New("pay tutarları negatif olamaz")
+			}
+			splits[i].UserID = in.UserID
+			splits[i].Amount = in.Amount
+			total += in.Amount
+		}
+		if total != amount {
+			return "", nil, fmt.Errorf("payların toplamı (%s) gider tutarına (%s) eşit olmalı", total, amount)
+		}
+		return mode, splits, nil
+	default:
+		return "", nil, fmt.Errorf("geçersiz bölüşüm modu: %s", mode)
+	}
+
+	parts, err := amount.Allocate(weights)
+	if err != nil {
+		return "", nil, err
+	}
+	for i, part := range parts {
+		splits[i].Amount = part
+	}
+	return mode, splits, nil
+}
+
+// ratioInputs eski split_ratio alanını yüzde tanımına çevirir: oluşturan ratio kadar,
+// kalan yüzde diğer üyelere on binde bir hassasiyetinde eşit dağıtılır.
+func ratioInputs(members []models.User, creatorID uint, ratio float64) ([]SplitInput, error) {
+	creatorBP := percentBasisPoints(ratio)
+	inputs := []SplitInput{{UserID: creatorID, Percentage: float64(creatorBP) / 100}}
+
+	var others []uint
+	for _, m := range members {
+		if m.ID != creatorID {
+			others = append(others, m.ID)
+		}
+	}
+	if len(others) == 0 {
+		return []SplitInput{{UserID: creatorID, Percentage: 100}}, nil
+	}
+	weights := make([]int64, len(others))
+	for i := range weights {
+		weights[i] = 1
+	}
+	// On binde birlik dilimler de kuruş gibi en büyük kalan yöntemiyle dağıtılır
+	parts, err := models.Money(10000 - creatorBP).Allocate(weights)
+	if err != nil {
+		return nil, err
+	}
+	for i, bp := range parts {
+		inputs = append(inputs, SplitInput{UserID: others[i], Percentage: float64(bp) / 100})
+	}
+	return inputs, nil
+}
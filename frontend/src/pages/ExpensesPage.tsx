@@ -37,7 +37,7 @@ export default function ExpensesPage() {
   const loadData = () => {
     setLoading(true);
     Promise.all([
-      getExpenses(month, year),
+      getExpenses(month, year, 'delete_requester'),
       getCategories(),
     ]).then(([expRes, catRes]) => {
       setExpenses(expRes.data);
@@ -37,8 +37,8 @@ export const createCategory = (data: { name: string; icon: string }) =>
   api.post<Category>('/categories', data);
 
 // Expenses
-export const getExpenses = (month: number, year: number) =>
-  api.get<Expense[]>('/expenses', { params: { month, year } });
+export const getExpenses = (month: number, year: number, include?: string) =>
+  api.get<Expense[]>('/expenses', { params: { month, year, include } });
 
 export const createExpense = (data: {
   category_id: number;